@@ -0,0 +1,71 @@
+// Package cobraconfig integrates configer with cobra commands. The flags of
+// the config options are registered on the command, and the configuration is
+// loaded after cobra parsed them, before the command runs.
+package cobraconfig
+
+import (
+	"fmt"
+
+	"github.com/Ozoniuss/configer"
+	"github.com/spf13/cobra"
+)
+
+// Bind registers the flags of the config options as local flags of cmd, and
+// loads the configuration into configStruct in the command's PreRunE hook,
+// before any PreRunE or PreRun hook that was previously set.
+//
+// Each subcommand may be bound to its own config options and struct. Use
+// configer.WithConfigSection to unmarshal only a section of the
+// configuration, e.g. "serve" for the options with keys "serve.*".
+func Bind(cmd *cobra.Command, configStruct interface{}, appOptions []configer.ConfigOption, parserOptions ...configer.ParserOption) error {
+	loader, err := configer.RegisterFlags(cmd.Flags(), appOptions, parserOptions...)
+	if err != nil {
+		return fmt.Errorf("could not register flags for command %s: %w", cmd.Name(), err)
+	}
+
+	preRunE, preRun := cmd.PreRunE, cmd.PreRun
+	cmd.PreRun = nil
+	cmd.PreRunE = func(c *cobra.Command, args []string) error {
+		if err := loader.Load(configStruct); err != nil {
+			return err
+		}
+		if preRunE != nil {
+			return preRunE(c, args)
+		}
+		if preRun != nil {
+			preRun(c, args)
+		}
+		return nil
+	}
+	return nil
+}
+
+// BindPersistent registers the flags of the config options as persistent
+// flags of cmd, such that they are also available to its subcommands, and
+// loads the configuration into configStruct in the command's
+// PersistentPreRunE hook, before any hook that was previously set.
+//
+// Note that cobra only runs the persistent hooks of the closest command
+// defining them, so subcommands should not define their own.
+func BindPersistent(cmd *cobra.Command, configStruct interface{}, appOptions []configer.ConfigOption, parserOptions ...configer.ParserOption) error {
+	loader, err := configer.RegisterFlags(cmd.PersistentFlags(), appOptions, parserOptions...)
+	if err != nil {
+		return fmt.Errorf("could not register persistent flags for command %s: %w", cmd.Name(), err)
+	}
+
+	preRunE, preRun := cmd.PersistentPreRunE, cmd.PersistentPreRun
+	cmd.PersistentPreRun = nil
+	cmd.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		if err := loader.Load(configStruct); err != nil {
+			return err
+		}
+		if preRunE != nil {
+			return preRunE(c, args)
+		}
+		if preRun != nil {
+			preRun(c, args)
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package cobraconfig
+
+import (
+	"testing"
+
+	"github.com/Ozoniuss/configer"
+	"github.com/spf13/cobra"
+)
+
+type ServeConfig struct {
+	Port int
+}
+
+type MigrateConfig struct {
+	Steps int
+}
+
+func TestBindSubcommands(t *testing.T) {
+	var serveCfg ServeConfig
+	var migrateCfg MigrateConfig
+	var ran string
+
+	root := &cobra.Command{Use: "app"}
+	serve := &cobra.Command{Use: "serve", RunE: func(cmd *cobra.Command, args []string) error {
+		ran = "serve"
+		return nil
+	}}
+	migrate := &cobra.Command{Use: "migrate", RunE: func(cmd *cobra.Command, args []string) error {
+		ran = "migrate"
+		return nil
+	}}
+	root.AddCommand(serve, migrate)
+
+	err := Bind(serve, &serveCfg, []configer.ConfigOption{
+		{FlagName: "port", Value: 8080, ConfigKey: "serve.port"},
+	}, configer.WithConfigSection("serve"), configer.WithConfigName("garbage"), configer.WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not bind serve command: %s", err.Error())
+	}
+	err = Bind(migrate, &migrateCfg, []configer.ConfigOption{
+		{FlagName: "steps", Value: 1, ConfigKey: "migrate.steps"},
+	}, configer.WithConfigSection("migrate"), configer.WithConfigName("garbage"), configer.WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not bind migrate command: %s", err.Error())
+	}
+
+	root.SetArgs([]string{"serve", "--port", "9090"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("could not execute command: %s", err.Error())
+	}
+
+	if ran != "serve" {
+		t.Fatalf("invalid command: want serve, got %s", ran)
+	}
+	if serveCfg.Port != 9090 {
+		t.Fatalf("invalid port: want %d, got %d", 9090, serveCfg.Port)
+	}
+	if migrateCfg.Steps != 0 {
+		t.Fatalf("migrate config was loaded for the serve command")
+	}
+}
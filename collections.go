@@ -0,0 +1,183 @@
+package configer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// priorityEnv is the priority of the layer holding the collections set via
+// indexed environment variables. These take precedence over every other
+// layer, but not over flags.
+const priorityEnv = math.MaxInt
+
+// decoderOptions returns the options used to decode the configuration to a
+// config struct.
+func decoderOptions(listSeparator string) viper.DecoderConfigOption {
+	return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		stringToCollectionHookFunc(listSeparator),
+	))
+}
+
+// stringToCollectionHookFunc returns a decode hook which converts strings,
+// such as the values of environment variables, to slices and maps. JSON
+// literals are decoded as JSON. Otherwise, slices are decoded from separated
+// lists and maps from separated key=value pairs.
+func stringToCollectionHookFunc(separator string) mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String {
+			return data, nil
+		}
+		s := data.(string)
+		trimmed := strings.TrimSpace(s)
+
+		switch to.Kind() {
+		case reflect.Slice:
+			// Bytes are decoded from the string directly.
+			if to.Elem().Kind() == reflect.Uint8 {
+				return data, nil
+			}
+			if strings.HasPrefix(trimmed, "[") {
+				var values []any
+				if err := json.Unmarshal([]byte(trimmed), &values); err != nil {
+					return nil, fmt.Errorf("invalid JSON list %s: %w", s, err)
+				}
+				return values, nil
+			}
+			if trimmed == "" {
+				return []string{}, nil
+			}
+			return strings.Split(s, separator), nil
+
+		case reflect.Map:
+			if strings.HasPrefix(trimmed, "{") {
+				var values map[string]any
+				if err := json.Unmarshal([]byte(trimmed), &values); err != nil {
+					return nil, fmt.Errorf("invalid JSON object %s: %w", s, err)
+				}
+				return values, nil
+			}
+			values := make(map[string]string)
+			if trimmed == "" {
+				return values, nil
+			}
+			for _, pair := range strings.Split(s, separator) {
+				k, v, ok := strings.Cut(pair, "=")
+				if !ok {
+					return nil, fmt.Errorf("invalid key=value pair %s", pair)
+				}
+				values[strings.TrimSpace(k)] = v
+			}
+			return values, nil
+		}
+		return data, nil
+	}
+}
+
+// readIndexedEnvVars collects the lists set via indexed environment
+// variables, for the config options whose value is a slice. The elements of
+// a list are set via PREFIX_KEY_<index>, and the fields of an element via
+// PREFIX_KEY_<index>_<field>, e.g. DEMO_SERVERS_0_HOST.
+func (p *configParser) readIndexedEnvVars(opts []ConfigOption) error {
+	values := make(map[string]any)
+	env := os.Environ()
+
+	for _, opt := range opts {
+		if opt.ConfigKey == "" || !isListValue(opt.Value) {
+			continue
+		}
+
+		prefix := p.envName(opt.ConfigKey) + "_"
+		if len(opt.EnvVars) != 0 {
+			prefix = opt.EnvVars[0] + "_"
+		}
+
+		list, err := indexedList(env, prefix)
+		if err != nil {
+			return fmt.Errorf("invalid environment variables for %s: %w", opt.ConfigKey, err)
+		}
+		if list != nil {
+			setNestedValue(values, opt.ConfigKey, list)
+		}
+	}
+
+	if len(values) != 0 {
+		p.addLayer("environment variables", priorityEnv, values)
+	}
+	return nil
+}
+
+// indexedList builds a list from the environment variables starting with
+// prefix, followed by an index. It returns nil if there are no such
+// variables.
+func indexedList(env []string, prefix string) ([]any, error) {
+	elements := make(map[int]any)
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, prefix) || value == "" {
+			continue
+		}
+
+		index, field, hasField := strings.Cut(strings.TrimPrefix(name, prefix), "_")
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 {
+			continue
+		}
+
+		if !hasField {
+			if _, ok := elements[i].(map[string]any); ok {
+				return nil, fmt.Errorf("element %d is set both as a value and via fields", i)
+			}
+			elements[i] = value
+			continue
+		}
+
+		element, ok := elements[i].(map[string]any)
+		if !ok {
+			if _, isValue := elements[i]; isValue {
+				return nil, fmt.Errorf("element %d is set both as a value and via fields", i)
+			}
+			element = make(map[string]any)
+			elements[i] = element
+		}
+		element[strings.ToLower(field)] = value
+	}
+
+	if len(elements) == 0 {
+		return nil, nil
+	}
+
+	indexes := make([]int, 0, len(elements))
+	for i := range elements {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	if indexes[len(indexes)-1] != len(indexes)-1 {
+		return nil, fmt.Errorf("indexes must be consecutive and start at 0")
+	}
+
+	list := make([]any, len(indexes))
+	for _, i := range indexes {
+		list[i] = elements[i]
+	}
+	return list, nil
+}
+
+// isListValue returns whether the value of a config option is a list. Bytes
+// are not considered lists.
+func isListValue(value any) bool {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return false
+	}
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8
+}
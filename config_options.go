@@ -33,4 +33,31 @@ type ConfigOption struct {
 	// the value of the config option with key "server.address" would get
 	// unmarshaled to the server's address in the struct.
 	ConfigKey string
+	// The environment variables the configuration option can be set with,
+	// in order of priority. If empty, the environment variable is derived
+	// from the config key, the env prefix and the env key replacer. If set,
+	// the derived variable is not used unless it is part of the list, and
+	// no prefix is added to the names.
+	//
+	// e.g. []string{"DEMO_DATABASE_URL", "DATABASE_URL"}
+	//
+	// It is an error to set two of these variables to different values.
+	EnvVars []string
+	// The variables from EnvVars which are deprecated. A warning is logged
+	// when the option is set through one of them.
+	DeprecatedEnvVars []string
+	// Whether the configuration option must be set explicitly, via a flag,
+	// an environment variable, the configuration file or another source. The
+	// config is not created if a required option is missing. Value must
+	// still be provided to determine the type of the flag, but is not used
+	// as a default.
+	Required bool
+	// Whether the value of the configuration option is sensitive, such as a
+	// password. Secret values are redacted in every output of the parser.
+	Secret bool
+	// Whether the process must be restarted to change the configuration
+	// option, such as a listen port. When reloading the configuration via a
+	// Reloader, changes of these options are rejected or ignored, depending
+	// on the reload policy.
+	RestartRequired bool
 }
@@ -0,0 +1,70 @@
+package configer
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// debugValue is a config key served by the debug handler.
+type debugValue struct {
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// debugConfig is the response of the debug handler.
+type debugConfig struct {
+	Keys     map[string]debugValue `json:"keys"`
+	Checksum string                `json:"checksum"`
+}
+
+// DebugHandler returns an http.Handler which serves the configuration as
+// JSON, usually mounted at /debug/config. Every config key is served with
+// its value and the source that supplied it, and secret values are redacted.
+// The response also holds the fingerprint of the configuration as checksum,
+// which excludes the secret values so that it cannot be used to guess them.
+//
+// The snapshot function is called for every request, such that the handler
+// reflects the changes of the configuration, e.g. by passing
+// Reloader.Snapshot. It must be safe for concurrent use.
+func DebugHandler(snapshot func() *Snapshot) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		s := snapshot()
+		response := debugConfig{
+			Keys:     make(map[string]debugValue),
+			Checksum: s.Fingerprint(true),
+		}
+		for _, key := range s.AllKeys() {
+			value := debugJSONValue(s.Get(key))
+			if s.IsSecret(key) {
+				value = redacted
+			}
+			response.Keys[key] = debugValue{
+				Value:  value,
+				Source: s.Source(key),
+				Secret: s.IsSecret(key),
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		// The response was already committed.
+		_ = enc.Encode(response)
+	})
+}
+
+// debugJSONValue converts the values which have no readable JSON encoding.
+func debugJSONValue(value any) any {
+	if d, ok := value.(time.Duration); ok {
+		return d.String()
+	}
+	return value
+}
@@ -0,0 +1,110 @@
+package configer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	resetFlags(t)
+	withArgs(t, "--0-stringg", "secret")
+	opts := getyamlopts()
+	opts[1].Secret = true
+
+	snapshot, err := NewSnapshot(opts, WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not create snapshot: %s", err.Error())
+	}
+
+	var mu sync.Mutex
+	current := snapshot
+	handler := DebugHandler(func() *Snapshot {
+		mu.Lock()
+		defer mu.Unlock()
+		return current
+	})
+
+	get := func() (debugConfig, string) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("invalid status code: want %d, got %d", http.StatusOK, rec.Code)
+		}
+		var response debugConfig
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("invalid response: %s", err.Error())
+		}
+		return response, rec.Body.String()
+	}
+
+	response, body := get()
+	if strings.Contains(body, `"value": "secret"`) {
+		t.Fatalf("secret value was not redacted:\n%s", body)
+	}
+	stringg := response.Keys["stringg"]
+	if stringg.Value != redacted || stringg.Source != "flag --0-stringg" || !stringg.Secret {
+		t.Fatalf("invalid secret key: %+v", stringg)
+	}
+	if durationn := response.Keys["durationn"]; durationn.Value != "2s" || durationn.Source != sourceDefault {
+		t.Fatalf("invalid duration key: %+v", durationn)
+	}
+	if !strings.HasPrefix(response.Checksum, "sha256:") {
+		t.Fatalf("invalid checksum: %s", response.Checksum)
+	}
+
+	resetFlags(t)
+	withArgs(t, "--0-stringg", "secret", "--0-numberr", "7")
+	changed, err := NewSnapshot(opts, WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not create snapshot: %s", err.Error())
+	}
+	mu.Lock()
+	current = changed
+	mu.Unlock()
+
+	changedResponse, _ := get()
+	if n := changedResponse.Keys["numberr"]; n.Value != float64(7) || n.Source != "flag --0-numberr" {
+		t.Fatalf("invalid changed key: %+v", n)
+	}
+	if changedResponse.Checksum == response.Checksum {
+		t.Fatalf("checksum did not change with the configuration")
+	}
+
+	// Requests are served while the configuration changes.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mu.Lock()
+			if i%2 == 0 {
+				current = snapshot
+			} else {
+				current = changed
+			}
+			mu.Unlock()
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+			var r debugConfig
+			if err := json.Unmarshal(rec.Body.Bytes(), &r); err != nil {
+				t.Errorf("invalid response: %s", err.Error())
+				return
+			}
+			if r.Checksum != response.Checksum && r.Checksum != changedResponse.Checksum {
+				t.Errorf("unexpected checksum %s", r.Checksum)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/config", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("invalid status code: want %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
@@ -0,0 +1,132 @@
+package configer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// ErrConfigDiffed is returned when the configuration diff requested via the
+// --diff-config flag was displayed. The config is not created in that case,
+// and the program should usually exit.
+var ErrConfigDiffed = errors.New("configuration diff displayed")
+
+// ChangeKind describes how the value of a config key changed.
+type ChangeKind string
+
+const (
+	// Added is used for keys which only have a value in the new
+	// configuration.
+	Added ChangeKind = "added"
+	// Removed is used for keys which only have a value in the old
+	// configuration.
+	Removed ChangeKind = "removed"
+	// Changed is used for keys whose value differs.
+	Changed ChangeKind = "changed"
+)
+
+// Change describes the difference between two configurations for a single
+// config key. The values of secret keys are redacted.
+type Change struct {
+	Key  string
+	Kind ChangeKind
+	// The values of the key in the old and new configuration. Nil if the key
+	// has no value in that configuration.
+	Old any
+	New any
+	// The sources that supplied the old and new value. Empty if the key has
+	// no value in that configuration.
+	OldSource string
+	NewSource string
+}
+
+// Diff returns the config keys which were added, removed or changed between
+// the configurations from and to, sorted by key. Values of keys that are
+// secret in either configuration are redacted.
+func Diff(from, to *Snapshot) []Change {
+	oldValues := flattenValues(from.settings)
+	newValues := flattenValues(to.settings)
+
+	var changes []Change
+	for key, oldValue := range oldValues {
+		newValue, ok := newValues[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: key, Kind: Removed, Old: oldValue, OldSource: from.sources[key]})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, Change{Key: key, Kind: Changed, Old: oldValue, New: newValue, OldSource: from.sources[key], NewSource: to.sources[key]})
+		}
+	}
+	for key, newValue := range newValues {
+		if _, ok := oldValues[key]; !ok {
+			changes = append(changes, Change{Key: key, Kind: Added, New: newValue, NewSource: to.sources[key]})
+		}
+	}
+
+	for i, change := range changes {
+		if isSecretKey(from.secrets, change.Key) || isSecretKey(to.secrets, change.Key) {
+			if change.Old != nil {
+				changes[i].Old = redacted
+			}
+			if change.New != nil {
+				changes[i].New = redacted
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// WriteDiff writes the changes in a human readable format to w.
+func WriteDiff(w io.Writer, changes []Change) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "no configuration changes")
+		return
+	}
+	for _, c := range changes {
+		switch c.Kind {
+		case Added:
+			fmt.Fprintf(w, "+ %s: %v (from %s)\n", c.Key, c.New, c.NewSource)
+		case Removed:
+			fmt.Fprintf(w, "- %s: %v (from %s)\n", c.Key, c.Old, c.OldSource)
+		case Changed:
+			fmt.Fprintf(w, "~ %s: %v (from %s) -> %v (from %s)\n", c.Key, c.Old, c.OldSource, c.New, c.NewSource)
+		}
+	}
+}
+
+// CandidateSnapshot returns a snapshot of the configuration the loader would
+// produce if the config was read from configFile instead of the current
+// config file. Flags, environment variables and other sources are read the
+// same way. It must be called after the flag set was parsed.
+func (l *Loader) CandidateSnapshot(configFile string) (*Snapshot, error) {
+	options := make([]ParserOption, 0, len(l.parserOptions)+1)
+	options = append(options, l.parserOptions...)
+	options = append(options, WithConfigFile(configFile))
+
+	candidate := &Loader{
+		parser:        newConfiguredParser(l.parser.flags, options...),
+		appOptions:    l.appOptions,
+		parserOptions: options,
+	}
+	if err := candidate.load(); err != nil {
+		return nil, fmt.Errorf("invalid candidate config %s: %w", configFile, err)
+	}
+	return candidate.parser.snapshot(l.appOptions), nil
+}
+
+// writeDiff writes the changes between the current configuration and the
+// candidate config file to w.
+func (l *Loader) writeDiff(w io.Writer, configFile string) error {
+	candidate, err := l.CandidateSnapshot(configFile)
+	if err != nil {
+		return err
+	}
+	WriteDiff(w, Diff(l.parser.snapshot(l.appOptions), candidate))
+	return ErrConfigDiffed
+}
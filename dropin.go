@@ -0,0 +1,55 @@
+package configer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// readDropInFiles reads the config fragments from the drop-in directories,
+// and stores each of them as a layer on top of the configuration file. The
+// fragments of a directory are merged in lexical order of their file names,
+// so that later fragments overwrite the values of earlier ones.
+func (p *configParser) readDropInFiles() error {
+	for _, dir := range p.dropInDirs {
+		// Entries are sorted by file name.
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				p.debugf("no drop-in directory at %s", dir)
+				continue
+			}
+			return fmt.Errorf("could not read drop-in directory %s: %w", dir, err)
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			fragment := filepath.Join(dir, name)
+
+			// Skip leftovers such as backups or editor swap files.
+			format := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+			if entry.IsDir() || strings.HasPrefix(name, ".") || !isSupportedFormat(format) {
+				p.debugf("skipping drop-in file %s", fragment)
+				continue
+			}
+
+			content, err := os.ReadFile(fragment)
+			if err != nil {
+				return fmt.Errorf("could not read config fragment %s: %w", fragment, err)
+			}
+			values, err := readConfigValues(bytes.NewReader(content), format)
+			if err != nil {
+				return fmt.Errorf("could not parse config fragment %s: %w", fragment, err)
+			}
+			values, err = p.resolveIncludes(fragment, values)
+			if err != nil {
+				return err
+			}
+			p.addLayer(fragment, PriorityFile, values)
+			p.log.Printf("[configer info] read config fragment at %s\n", fragment)
+		}
+	}
+	return nil
+}
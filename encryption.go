@@ -0,0 +1,379 @@
+package configer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Encrypted values are stored in configuration files with the format below,
+// where every field is base64 encoded:
+//
+//	ENC[AES256_GCM,data:<ciphertext>,iv:<nonce>,tag:<authentication tag>]
+const (
+	encryptedPrefix = "ENC[AES256_GCM,"
+	encryptedSuffix = "]"
+	keySize         = 32
+	nonceSize       = 12
+	tagSize         = 16
+)
+
+var encryptedValueRegexp = regexp.MustCompile(`ENC\[AES256_GCM,[^\]]*\]`)
+
+// GenerateKey returns a new random key which can be used to encrypt
+// configuration values, base64 encoded. The key can be stored in a file or
+// an environment variable, and provided to the parser via
+// WithDecryptionKeyFile or WithDecryptionKeyEnv.
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("could not generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EncryptValue encrypts a configuration value with the base64 encoded key,
+// returning the value in the format the parser decrypts when reading
+// configuration files.
+func EncryptValue(key string, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("could not generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nil, nonce, []byte(value), nil)
+	data, tag := sealed[:len(sealed)-tagSize], sealed[len(sealed)-tagSize:]
+
+	return fmt.Sprintf("%sdata:%s,iv:%s,tag:%s%s",
+		encryptedPrefix,
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(nonce),
+		base64.StdEncoding.EncodeToString(tag),
+		encryptedSuffix,
+	), nil
+}
+
+// DecryptValue decrypts a value encrypted with EncryptValue, using the same
+// base64 encoded key.
+func DecryptValue(key string, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	return decryptWith(gcm, value)
+}
+
+// ReencryptFile decrypts every encrypted value of the configuration file at
+// path with oldKey, and encrypts it again with newKey. The rest of the file is
+// left unchanged.
+func ReencryptFile(path string, oldKey, newKey string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read file %s: %w", path, err)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("could not get stats for file %s: %w", path, err)
+	}
+
+	oldGCM, err := newGCM(oldKey)
+	if err != nil {
+		return fmt.Errorf("invalid old key: %w", err)
+	}
+
+	var replaceErr error
+	reencrypted := encryptedValueRegexp.ReplaceAllFunc(content, func(match []byte) []byte {
+		if replaceErr != nil {
+			return match
+		}
+		var plaintext, value string
+		plaintext, replaceErr = decryptWith(oldGCM, string(match))
+		if replaceErr != nil {
+			return match
+		}
+		value, replaceErr = EncryptValue(newKey, plaintext)
+		return []byte(value)
+	})
+	if replaceErr != nil {
+		return fmt.Errorf("could not re-encrypt file %s: %w", path, replaceErr)
+	}
+
+	// The file may hold the only copy of the secrets, so it must never be
+	// left partially written.
+	if err := writeFileAtomic(path, reencrypted, stat.Mode().Perm()); err != nil {
+		return fmt.Errorf("could not write file %s: %w", path, err)
+	}
+	return nil
+}
+
+// isEncrypted returns whether the configuration value is encrypted.
+func isEncrypted(value any) bool {
+	s, ok := value.(string)
+	return ok && strings.HasPrefix(s, encryptedPrefix) && strings.HasSuffix(s, encryptedSuffix)
+}
+
+func newGCM(key string) (cipher.AEAD, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("key is not base64 encoded: %w", err)
+	}
+	if len(raw) != keySize {
+		return nil, fmt.Errorf("key must have %d bytes, got %d", keySize, len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func decryptWith(gcm cipher.AEAD, value string) (string, error) {
+	if !isEncrypted(value) {
+		return "", errors.New("value is not encrypted")
+	}
+
+	fields := make(map[string][]byte)
+	content := strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix)
+	for _, field := range strings.Split(content, ",") {
+		name, encoded, ok := strings.Cut(field, ":")
+		if !ok {
+			return "", fmt.Errorf("invalid encrypted field %q", field)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", fmt.Errorf("encrypted field %s is not base64 encoded: %w", name, err)
+		}
+		fields[name] = decoded
+	}
+
+	data, nonce, tag := fields["data"], fields["iv"], fields["tag"]
+	if len(nonce) != nonceSize || len(tag) != tagSize {
+		return "", errors.New("invalid encrypted value")
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, append(data, tag...), nil)
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// encryptedValue stores a value decrypted from a configuration source.
+type encryptedValue struct {
+	original  any
+	decrypted any
+}
+
+// readDecryptionKey returns the key configured via WithDecryptionKeyEnv or
+// WithDecryptionKeyFile. The environment variable takes precedence over the
+// file.
+func (p *configParser) readDecryptionKey() (string, error) {
+	if p.decryptionKeyEnv != "" {
+		if key, ok := os.LookupEnv(p.decryptionKeyEnv); ok {
+			return key, nil
+		}
+	}
+	if p.decryptionKeyFile != "" {
+		key, err := os.ReadFile(p.decryptionKeyFile)
+		if err != nil {
+			return "", fmt.Errorf("could not read decryption key file: %w", err)
+		}
+		return string(key), nil
+	}
+	return "", errors.New("no decryption key provided")
+}
+
+// decryptionGCM returns the cipher used to decrypt the configuration values.
+func (p *configParser) decryptionGCM() (cipher.AEAD, error) {
+	encoded, err := p.readDecryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid decryption key: %w", err)
+	}
+	return gcm, nil
+}
+
+// decryptValues decrypts the encrypted values of the configuration in place.
+// The original values are stored by the parser, to avoid writing the
+// decrypted values in plaintext.
+func (p *configParser) decryptValues(values map[string]any) error {
+	var gcm cipher.AEAD
+	for key, value := range flattenValues(values) {
+		if !containsEncrypted(value) {
+			continue
+		}
+
+		if gcm == nil {
+			var err error
+			gcm, err = p.decryptionGCM()
+			if err != nil {
+				return fmt.Errorf("could not decrypt value of key %s: %w", key, err)
+			}
+		}
+
+		decrypted, err := decryptAny(gcm, value)
+		if err != nil {
+			return fmt.Errorf("could not decrypt value of key %s: %w", key, err)
+		}
+		setNestedValue(values, key, decrypted)
+		p.encrypted[key] = encryptedValue{
+			original:  value,
+			decrypted: decrypted,
+		}
+	}
+	return nil
+}
+
+// decryptEnvValues decrypts the encrypted values set via environment
+// variables. viper reads the variables on access, so the decrypted values
+// overwrite them, unless the value was set via flag.
+func (p *configParser) decryptEnvValues(opts []ConfigOption) error {
+	byKey := make(map[string]ConfigOption, len(opts))
+	for _, opt := range opts {
+		byKey[strings.ToLower(opt.ConfigKey)] = opt
+	}
+
+	var gcm cipher.AEAD
+	for key := range p.envKeys {
+		opt, ok := byKey[key]
+		if !ok {
+			opt = ConfigOption{ConfigKey: key}
+		}
+		if opt.FlagName != "" {
+			if f := p.flags.Lookup(opt.FlagName); f != nil && f.Changed {
+				continue
+			}
+		}
+
+		for _, name := range p.optionEnvVars(opt) {
+			// viper ignores empty variables by default.
+			value, ok := os.LookupEnv(name)
+			if !ok || value == "" {
+				continue
+			}
+			if !isEncrypted(value) {
+				break
+			}
+
+			if gcm == nil {
+				var err error
+				gcm, err = p.decryptionGCM()
+				if err != nil {
+					return fmt.Errorf("could not decrypt environment variable %s: %w", name, err)
+				}
+			}
+			decrypted, err := decryptWith(gcm, value)
+			if err != nil {
+				return fmt.Errorf("could not decrypt environment variable %s: %w", name, err)
+			}
+			p.viper.Set(key, decrypted)
+			p.encrypted[key] = encryptedValue{
+				original:  value,
+				decrypted: decrypted,
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// encryptedSettings replaces the values that were decrypted with their
+// encrypted form. Decrypted values which were overwritten by environment
+// variables or flags are encrypted again, which is only possible for
+// strings.
+func (p *configParser) encryptedSettings(settings map[string]any) (map[string]any, error) {
+	for key, enc := range p.encrypted {
+		// Some keys may be left out when writing.
+		current, ok := getNestedValue(settings, key)
+		if !ok {
+			continue
+		}
+		if reflect.DeepEqual(current, enc.decrypted) {
+			setNestedValue(settings, key, enc.original)
+			continue
+		}
+
+		plaintext, ok := current.(string)
+		if !ok {
+			return nil, fmt.Errorf("could not encrypt value of key %s: only strings can be encrypted, got %T", key, current)
+		}
+		encoded, err := p.readDecryptionKey()
+		if err != nil {
+			return nil, err
+		}
+		value, err := EncryptValue(encoded, plaintext)
+		if err != nil {
+			return nil, fmt.Errorf("could not encrypt value of key %s: %w", key, err)
+		}
+		setNestedValue(settings, key, value)
+	}
+	return settings, nil
+}
+
+// containsEncrypted returns whether a configuration value, or one of the
+// values nested in it, is encrypted.
+func containsEncrypted(value any) bool {
+	if m, ok := toStringMap(value); ok {
+		for _, v := range m {
+			if containsEncrypted(v) {
+				return true
+			}
+		}
+		return false
+	}
+	if list, ok := value.([]any); ok {
+		for _, v := range list {
+			if containsEncrypted(v) {
+				return true
+			}
+		}
+		return false
+	}
+	return isEncrypted(value)
+}
+
+// decryptAny decrypts a configuration value, or the values nested in lists
+// and maps, such as lists of structs.
+func decryptAny(gcm cipher.AEAD, value any) (any, error) {
+	if m, ok := toStringMap(value); ok {
+		decrypted := make(map[string]any, len(m))
+		for k, v := range m {
+			d, err := decryptAny(gcm, v)
+			if err != nil {
+				return nil, err
+			}
+			decrypted[k] = d
+		}
+		return decrypted, nil
+	}
+	if list, ok := value.([]any); ok {
+		decrypted := make([]any, len(list))
+		for i, v := range list {
+			d, err := decryptAny(gcm, v)
+			if err != nil {
+				return nil, err
+			}
+			decrypted[i] = d
+		}
+		return decrypted, nil
+	}
+	if !isEncrypted(value) {
+		return value, nil
+	}
+	return decryptWith(gcm, value.(string))
+}
@@ -0,0 +1,240 @@
+package configer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadEncryptedValues(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %s", err.Error())
+	}
+	encrypted, err := EncryptValue(key, "secret")
+	if err != nil {
+		t.Fatalf("could not encrypt value: %s", err.Error())
+	}
+
+	f, dir := initFile(t, "test.yml", []byte("numberr: 13\nstringg: "+encrypted+"\n"))
+	defer f.Close()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte(key), 0600); err != nil {
+		t.Fatalf("could not write key: %s", err.Error())
+	}
+
+	resetFlags(t)
+
+	ex := Example1{}
+	err = NewConfig(&ex, getyamlopts(),
+		WithConfigName("test"),
+		WithConfigType("yml"),
+		WithConfigPath(dir),
+		WithDecryptionKeyFile(keyFile),
+		WithSupressLogs())
+
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+
+	if ex.Stringg != "secret" {
+		t.Fatalf("invalid string: want %s, got %s", "secret", ex.Stringg)
+	}
+}
+
+func TestReencryptFile(t *testing.T) {
+	oldKey, _ := GenerateKey()
+	newKey, _ := GenerateKey()
+
+	encrypted, err := EncryptValue(oldKey, "secret")
+	if err != nil {
+		t.Fatalf("could not encrypt value: %s", err.Error())
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("# comment\npassword: "+encrypted+"\n"), 0600); err != nil {
+		t.Fatalf("could not write config: %s", err.Error())
+	}
+
+	if err := ReencryptFile(path, oldKey, newKey); err != nil {
+		t.Fatalf("could not re-encrypt file: %s", err.Error())
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("temporary files were left behind: %v", entries)
+	}
+	stat, _ := os.Stat(path)
+	if stat.Mode().Perm() != 0o600 {
+		t.Fatalf("permissions were not kept: want %v, got %v", os.FileMode(0o600), stat.Mode().Perm())
+	}
+
+	content, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(content), "# comment\npassword: ") {
+		t.Fatalf("file was not preserved: %s", content)
+	}
+	value := strings.TrimSpace(strings.TrimPrefix(string(content), "# comment\npassword: "))
+	if _, err := DecryptValue(oldKey, value); err == nil {
+		t.Fatalf("value can still be decrypted with the old key")
+	}
+	decrypted, err := DecryptValue(newKey, value)
+	if err != nil {
+		t.Fatalf("could not decrypt with new key: %s", err.Error())
+	}
+	if decrypted != "secret" {
+		t.Fatalf("invalid value: want %s, got %s", "secret", decrypted)
+	}
+}
+
+func TestEncryptedValuesRedactedAndWritten(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %s", err.Error())
+	}
+	encrypted, err := EncryptValue(key, "secret")
+	if err != nil {
+		t.Fatalf("could not encrypt value: %s", err.Error())
+	}
+
+	f, dir := initFile(t, "test.yml", []byte("numberr: 13\nstringg: "+encrypted+"\n"))
+	defer f.Close()
+	t.Setenv("TEST_CONFIG_KEY", key)
+
+	options := []ParserOption{
+		WithConfigName("test"),
+		WithConfigPath(dir),
+		WithDecryptionKeyEnv("TEST_CONFIG_KEY"),
+		WithWriteFlag(),
+		WithSupressLogs(),
+	}
+
+	resetFlags(t)
+	withArgs(t)
+	snapshot, err := NewSnapshot(getyamlopts(), options...)
+	if err != nil {
+		t.Fatalf("could not create snapshot: %s", err.Error())
+	}
+	if !snapshot.IsSecret("stringg") || snapshot.IsSecret("numberr") {
+		t.Fatalf("decrypted value is not redacted")
+	}
+	if snapshot.GetString("stringg") != "secret" {
+		t.Fatalf("invalid string: want %s, got %s", "secret", snapshot.GetString("stringg"))
+	}
+
+	out := filepath.Join(t.TempDir(), "out.yml")
+	resetFlags(t)
+	withArgs(t, "--write-config", out)
+	if err := NewConfig(&Example1{}, getyamlopts(), options...); err != nil {
+		t.Fatalf("could not write config: %s", err.Error())
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("config was not written: %s", err.Error())
+	}
+	if strings.Contains(string(content), "secret") || !strings.Contains(string(content), encryptedPrefix) {
+		t.Fatalf("decrypted value was not written encrypted:\n%s", content)
+	}
+	stat, err := os.Stat(out)
+	if err != nil {
+		t.Fatalf("could not get stats of written config: %s", err.Error())
+	}
+	if stat.Mode().Perm() != 0o600 {
+		t.Fatalf("invalid permissions: want %v, got %v", os.FileMode(0o600), stat.Mode().Perm())
+	}
+}
+
+func TestReadEncryptedEnvAndNestedValues(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %s", err.Error())
+	}
+	encrypted, err := EncryptValue(key, "secret")
+	if err != nil {
+		t.Fatalf("could not encrypt value: %s", err.Error())
+	}
+
+	f, dir := initFile(t, "test.yml", []byte("servers:\n  - name: a\n    password: "+encrypted+"\n"))
+	defer f.Close()
+	t.Setenv("TEST_CONFIG_KEY", key)
+	t.Setenv("APP_STRINGG", encrypted)
+
+	options := []ParserOption{
+		WithConfigName("test"),
+		WithConfigPath(dir),
+		WithEnvPrefix("APP"),
+		WithDecryptionKeyEnv("TEST_CONFIG_KEY"),
+		WithWriteFlag(),
+		WithSupressLogs(),
+	}
+
+	resetFlags(t)
+	withArgs(t)
+	snapshot, err := NewSnapshot(getyamlopts(), options...)
+	if err != nil {
+		t.Fatalf("could not create snapshot: %s", err.Error())
+	}
+	if s := snapshot.GetString("stringg"); s != "secret" {
+		t.Fatalf("invalid string: want %s, got %s", "secret", s)
+	}
+	if source := snapshot.Source("stringg"); source != "environment variable APP_STRINGG" {
+		t.Fatalf("invalid source of stringg: %s", source)
+	}
+	if !snapshot.IsSecret("stringg") {
+		t.Fatalf("decrypted environment variable is not secret")
+	}
+	servers, ok := snapshot.Get("servers").([]any)
+	if !ok || len(servers) != 1 {
+		t.Fatalf("invalid servers: %v", snapshot.Get("servers"))
+	}
+	if password := servers[0].(map[string]any)["password"]; password != "secret" {
+		t.Fatalf("invalid nested password: want %s, got %v", "secret", password)
+	}
+
+	out := filepath.Join(t.TempDir(), "out.yml")
+	resetFlags(t)
+	withArgs(t, "--write-config", out)
+	if err := NewConfig(&Example1{}, getyamlopts(), options...); err != nil {
+		t.Fatalf("could not write config: %s", err.Error())
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("config was not written: %s", err.Error())
+	}
+	if strings.Contains(string(content), "secret") {
+		t.Fatalf("decrypted value was written in plaintext:\n%s", content)
+	}
+}
+
+func TestWriteOverwrittenEncryptedValue(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %s", err.Error())
+	}
+	encrypted, err := EncryptValue(key, "13")
+	if err != nil {
+		t.Fatalf("could not encrypt value: %s", err.Error())
+	}
+
+	f, dir := initFile(t, "test.yml", []byte("numberr: "+encrypted+"\n"))
+	defer f.Close()
+	t.Setenv("TEST_CONFIG_KEY", key)
+
+	// Only strings can be encrypted again, rather than their printed form.
+	out := filepath.Join(t.TempDir(), "out.yml")
+	resetFlags(t)
+	withArgs(t, "--write-config", out, "--0-numberr", "14")
+	err = NewConfig(&Example1{}, getyamlopts(),
+		WithConfigName("test"),
+		WithConfigPath(dir),
+		WithDecryptionKeyEnv("TEST_CONFIG_KEY"),
+		WithWriteFlag(),
+		WithSupressLogs())
+	if err == nil || !strings.Contains(err.Error(), "only strings can be encrypted") {
+		t.Fatalf("expected error when encrypting a number, got %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("config was written")
+	}
+}
@@ -0,0 +1,122 @@
+package configer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// bindEnvVars binds the config options to their environment variables: the
+// ones that were explicitly provided for them, or otherwise the variable
+// derived from the config key. It returns an error if the same variable is
+// bound to multiple options, or if multiple variables of an option are set
+// to different values.
+func (p *configParser) bindEnvVars(opts []ConfigOption) error {
+	owners := make(map[string]string)
+	for _, opt := range opts {
+		if len(opt.EnvVars) == 0 {
+			if opt.ConfigKey != "" {
+				p.bindDerivedEnvVar(opt.ConfigKey)
+			}
+			continue
+		}
+		if opt.ConfigKey == "" {
+			return fmt.Errorf("environment variables provided for option %s with no config key", opt.FlagName)
+		}
+
+		for _, name := range opt.EnvVars {
+			if owner, ok := owners[name]; ok && owner != opt.ConfigKey {
+				return fmt.Errorf("environment variable %s is bound to both %s and %s", name, owner, opt.ConfigKey)
+			}
+			owners[name] = opt.ConfigKey
+		}
+
+		if err := p.checkEnvVars(opt); err != nil {
+			return err
+		}
+
+		// viper looks up the variables in the order they are provided, and
+		// doesn't add the prefix to explicit names.
+		args := append([]string{opt.ConfigKey}, opt.EnvVars...)
+		if err := p.viper.BindEnv(args...); err != nil {
+			return fmt.Errorf("could not bind environment variables of %s: %w", opt.ConfigKey, err)
+		}
+		p.envKeys[strings.ToLower(opt.ConfigKey)] = true
+	}
+	return nil
+}
+
+// bindSettingsEnvVars binds the config keys which are not associated with an
+// option, such as keys only present in the configuration file, to the
+// variables derived from them.
+func (p *configParser) bindSettingsEnvVars() {
+	for key := range flattenValues(p.viper.AllSettings()) {
+		p.bindDerivedEnvVar(key)
+	}
+}
+
+// bindDerivedEnvVar binds a config key to the environment variable derived
+// from it, unless the key was already bound.
+func (p *configParser) bindDerivedEnvVar(key string) {
+	key = strings.ToLower(key)
+	if p.envKeys[key] {
+		return
+	}
+	// A single key never fails to bind. viper adds the prefix, and applies
+	// the replacer on lookup.
+	_ = p.viper.BindEnv(key)
+	p.envKeys[key] = true
+}
+
+// checkEnvVars verifies that the environment variables of an option don't
+// conflict, and warns if the option is set through a deprecated variable.
+func (p *configParser) checkEnvVars(opt ConfigOption) error {
+	var used, value string
+	for _, name := range opt.EnvVars {
+		// viper ignores empty variables by default.
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			continue
+		}
+
+		if used != "" {
+			if v != value {
+				return fmt.Errorf("conflicting values for %s set via environment variables %s and %s", opt.ConfigKey, used, name)
+			}
+			continue
+		}
+		used, value = name, v
+	}
+
+	for _, deprecated := range opt.DeprecatedEnvVars {
+		if deprecated == used {
+			p.log.Printf("[configer warn] environment variable %s is deprecated, use %s instead to set %s\n", used, opt.EnvVars[0], opt.ConfigKey)
+		}
+	}
+	return nil
+}
+
+// optionEnvVars returns the environment variables a config option can be set
+// with.
+func (p *configParser) optionEnvVars(opt ConfigOption) []string {
+	if len(opt.EnvVars) != 0 {
+		return opt.EnvVars
+	}
+	return []string{p.envName(opt.ConfigKey)}
+}
+
+// optionEnvNames describes the environment variables a config option can be
+// set with, marking the deprecated ones.
+func (p *configParser) optionEnvNames(opt ConfigOption) string {
+	names := make([]string, 0, len(opt.EnvVars))
+	for _, name := range p.optionEnvVars(opt) {
+		for _, deprecated := range opt.DeprecatedEnvVars {
+			if name == deprecated {
+				name += " (deprecated)"
+				break
+			}
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,166 @@
+package configer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cast"
+)
+
+// Fingerprint returns a hash of the configuration, which is equal for equal
+// configurations regardless of the sources that supplied the values. Keys
+// are sorted and values are normalized, such that e.g. the int 8080 and the
+// string "8080" have the same fingerprint. The values of options are first
+// decoded to the type of the option, such that e.g. the durations "1m" and
+// "60s" have the same fingerprint. If excludeSecrets is set, the secret values
+// do not contribute to the hash, so that it cannot be used to guess them.
+func (s *Snapshot) Fingerprint(excludeSecrets bool) string {
+	return fingerprint(s.settings, s.secrets, s.types, s.listSeparator, excludeSecrets)
+}
+
+// fingerprint returns the fingerprint of the configuration values. The
+// types map the config keys of options to their default values, whose types
+// are used to decode the values before normalizing them.
+func fingerprint(settings map[string]any, secrets map[string]bool, types map[string]any, listSeparator string, excludeSecrets bool) string {
+	canonical := make(map[string]any)
+	for key, prototype := range types {
+		value, ok := getNestedValue(settings, key)
+		if !ok {
+			continue
+		}
+		if decoded, err := decodeValue(value, prototype, listSeparator); err == nil {
+			canonical[key] = normalizeValue(decoded)
+		}
+	}
+	for key, value := range flattenValues(settings) {
+		if !hasTypedParent(canonical, types, key) {
+			canonical[key] = normalizeValue(value)
+		}
+	}
+	if excludeSecrets {
+		for key := range canonical {
+			if isSecretKey(secrets, key) {
+				canonical[key] = redacted
+			}
+		}
+	}
+
+	// Maps are encoded with sorted keys, and normalized values always have
+	// an encoding.
+	encoded, _ := json.Marshal(canonical)
+	sum := sha256.Sum256(encoded)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// hasTypedParent returns whether the config key, or one of its parents, was
+// already normalized using the type of its option.
+func hasTypedParent(canonical map[string]any, types map[string]any, key string) bool {
+	for {
+		if _, ok := types[key]; ok {
+			if _, ok := canonical[key]; ok {
+				return true
+			}
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
+
+// decodeValue decodes a configuration value to the type of prototype, the
+// same way it is decoded to a config struct.
+func decodeValue(value any, prototype any, listSeparator string) (any, error) {
+	result := reflect.New(reflect.TypeOf(prototype))
+	config := &mapstructure.DecoderConfig{
+		Result:           result.Interface(),
+		WeaklyTypedInput: true,
+	}
+	decoderOptions(listSeparator)(config)
+	decoder, err := mapstructure.NewDecoder(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(value); err != nil {
+		return nil, err
+	}
+	return result.Elem().Interface(), nil
+}
+
+// optionTypes returns the default values of the options with a config key,
+// by lowercase config key.
+func optionTypes(opts []ConfigOption) map[string]any {
+	types := make(map[string]any, len(opts))
+	for _, opt := range opts {
+		if opt.ConfigKey == "" || opt.Value == nil {
+			continue
+		}
+		types[strings.ToLower(opt.ConfigKey)] = opt.Value
+	}
+	return types
+}
+
+// normalizeValue converts a configuration value to a form which doesn't
+// depend on the type the source decoded it to. Scalars are converted to
+// strings, and maps and slices are normalized recursively.
+func normalizeValue(value any) any {
+	if m, ok := toStringMap(value); ok {
+		normalized := make(map[string]any, len(m))
+		for k, v := range m {
+			normalized[strings.ToLower(k)] = normalizeValue(v)
+		}
+		return normalized
+	}
+	if _, ok := value.([]byte); !ok && value != nil {
+		if v := reflect.ValueOf(value); v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			normalized := make([]any, v.Len())
+			for i := range normalized {
+				normalized[i] = normalizeValue(v.Index(i).Interface())
+			}
+			return normalized
+		}
+	}
+	if s, err := cast.ToStringE(value); err == nil {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// fingerprintVarsMu serializes looking up and publishing the fingerprint
+// variables, since expvar panics if a variable is published twice.
+var fingerprintVarsMu sync.Mutex
+
+// publishFingerprint logs the fingerprint of the parser's configuration and
+// publishes it via expvar, if enabled.
+func (p *configParser) publishFingerprint(opts []ConfigOption) {
+	if !p.fingerprint {
+		return
+	}
+	fp := fingerprint(p.viper.AllSettings(), p.secretKeys(), optionTypes(opts), p.listSeparator, p.fingerprintExcludeSecrets)
+	p.log.Printf("[configer info] config fingerprint %s\n", fp)
+
+	if p.fingerprintVar == "" {
+		return
+	}
+	// Variables cannot be published twice, e.g. after a reload, and may be
+	// published by multiple parsers at once.
+	fingerprintVarsMu.Lock()
+	defer fingerprintVarsMu.Unlock()
+	v, ok := expvar.Get(p.fingerprintVar).(*expvar.String)
+	if !ok {
+		if expvar.Get(p.fingerprintVar) != nil {
+			p.log.Printf("[configer warn] could not publish config fingerprint: expvar %s already exists\n", p.fingerprintVar)
+			return
+		}
+		v = expvar.NewString(p.fingerprintVar)
+	}
+	v.Set(fp)
+}
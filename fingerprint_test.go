@@ -0,0 +1,134 @@
+package configer
+
+import (
+	"expvar"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	secrets := map[string]bool{"password": true}
+	typed := map[string]any{
+		"port":     8080,
+		"timeout":  30 * time.Second,
+		"hosts":    []string{"a", "b"},
+		"password": "secret",
+	}
+	decoded := map[string]any{
+		"hosts":    []any{"a", "b"},
+		"timeout":  "30s",
+		"port":     "8080",
+		"password": "secret",
+	}
+	if fingerprint(typed, secrets, nil, ",", false) != fingerprint(decoded, secrets, nil, ",", false) {
+		t.Fatalf("equal configurations have different fingerprints")
+	}
+
+	decoded["password"] = "other"
+	if fingerprint(typed, secrets, nil, ",", false) == fingerprint(decoded, secrets, nil, ",", false) {
+		t.Fatalf("different secrets have the same fingerprint")
+	}
+	if fingerprint(typed, secrets, nil, ",", true) != fingerprint(decoded, secrets, nil, ",", true) {
+		t.Fatalf("excluded secrets changed the fingerprint")
+	}
+
+	decoded["port"] = 8081
+	if fingerprint(typed, secrets, nil, ",", true) == fingerprint(decoded, secrets, nil, ",", true) {
+		t.Fatalf("different configurations have the same fingerprint")
+	}
+}
+
+func TestFingerprintOptionTypes(t *testing.T) {
+	types := map[string]any{
+		"timeout": time.Second,
+		"hosts":   []string{},
+	}
+	typed := map[string]any{
+		"timeout": time.Minute,
+		"hosts":   []string{"a", "b"},
+	}
+	decoded := map[string]any{
+		"timeout": "1m",
+		"hosts":   "a,b",
+	}
+	if fingerprint(typed, nil, types, ",", false) != fingerprint(decoded, nil, types, ",", false) {
+		t.Fatalf("equal configurations have different fingerprints")
+	}
+
+	decoded["timeout"] = "61s"
+	if fingerprint(typed, nil, types, ",", false) == fingerprint(decoded, nil, types, ",", false) {
+		t.Fatalf("different configurations have the same fingerprint")
+	}
+}
+
+func TestSnapshotFingerprintDuration(t *testing.T) {
+	resetFlags(t)
+	withArgs(t)
+	opts := getyamlopts()
+	opts[3].Value = time.Minute
+	fromDefault, err := NewSnapshot(opts, WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not create snapshot: %s", err.Error())
+	}
+
+	resetFlags(t)
+	withArgs(t)
+	f, _ := initFile(t, "test.yml", []byte("durationn: 1m\n"))
+	defer f.Close()
+	fromFile, err := NewSnapshot(getyamlopts(), WithConfigFile(f.Name()), WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not create snapshot: %s", err.Error())
+	}
+
+	if fromDefault.GetDuration("durationn") != fromFile.GetDuration("durationn") {
+		t.Fatalf("snapshots have different durations")
+	}
+	if fromDefault.Fingerprint(false) != fromFile.Fingerprint(false) {
+		t.Fatalf("equal configurations have different fingerprints")
+	}
+}
+
+func TestPublishFingerprint(t *testing.T) {
+	resetFlags(t)
+	withArgs(t)
+	snapshot, err := NewSnapshot(getyamlopts(),
+		WithFingerprint("configer_test_fingerprint", true),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not create snapshot: %s", err.Error())
+	}
+
+	v, ok := expvar.Get("configer_test_fingerprint").(*expvar.String)
+	if !ok {
+		t.Fatalf("fingerprint was not published")
+	}
+	if v.Value() != snapshot.Fingerprint(true) {
+		t.Fatalf("invalid published fingerprint: want %s, got %s", snapshot.Fingerprint(true), v.Value())
+	}
+}
+
+func TestPublishFingerprintConcurrently(t *testing.T) {
+	parsers := make([]*configParser, 8)
+	for i := range parsers {
+		parsers[i] = newParser()
+		parsers[i].log.SetOutput(io.Discard)
+		parsers[i].fingerprint = true
+		parsers[i].fingerprintVar = "configer_test_fingerprint_concurrent"
+	}
+
+	var wg sync.WaitGroup
+	for _, p := range parsers {
+		wg.Add(1)
+		go func(p *configParser) {
+			defer wg.Done()
+			p.publishFingerprint(nil)
+		}(p)
+	}
+	wg.Wait()
+
+	if _, ok := expvar.Get("configer_test_fingerprint_concurrent").(*expvar.String); !ok {
+		t.Fatalf("fingerprint was not published")
+	}
+}
@@ -8,12 +8,13 @@ import (
 )
 
 // defineFlags defines all the flags that have been introduced through
-// configuration options.
-func defineFlags(configOptions []ConfigOption) error {
+// configuration options on the provided flag set.
+func defineFlags(flags *pflag.FlagSet, configOptions []ConfigOption) error {
 
 	for _, opt := range configOptions {
 
-		if opt.FlagName == readFlagName() || opt.FlagName == writeFlagName() {
+		switch opt.FlagName {
+		case readFlagName(), writeFlagName(), diffFlagName(), configTypeFlagName():
 			return fmt.Errorf("cannot use reserved flag name: %s", opt.FlagName)
 		}
 
@@ -24,18 +25,22 @@ func defineFlags(configOptions []ConfigOption) error {
 		if opt.FlagName != "" {
 			switch opt.Value.(type) {
 			case bool:
-				pflag.BoolP(opt.FlagName, opt.Shorthand, opt.Value.(bool), opt.Usage)
+				flags.BoolP(opt.FlagName, opt.Shorthand, opt.Value.(bool), opt.Usage)
 			case string:
-				pflag.StringP(opt.FlagName, opt.Shorthand, opt.Value.(string), opt.Usage)
+				flags.StringP(opt.FlagName, opt.Shorthand, opt.Value.(string), opt.Usage)
 			case int:
-				pflag.IntP(opt.FlagName, opt.Shorthand, opt.Value.(int), opt.Usage)
+				flags.IntP(opt.FlagName, opt.Shorthand, opt.Value.(int), opt.Usage)
 			case int32:
-				pflag.Int32P(opt.FlagName, opt.Shorthand, opt.Value.(int32), opt.Usage)
+				flags.Int32P(opt.FlagName, opt.Shorthand, opt.Value.(int32), opt.Usage)
 			case time.Duration:
-				pflag.DurationP(opt.FlagName, opt.Shorthand, opt.Value.(time.Duration), opt.Usage)
+				flags.DurationP(opt.FlagName, opt.Shorthand, opt.Value.(time.Duration), opt.Usage)
 			// Byte flag values are stored as hex.
 			case []byte:
-				pflag.BytesHexP(opt.FlagName, opt.Shorthand, opt.Value.([]byte), opt.Usage)
+				flags.BytesHexP(opt.FlagName, opt.Shorthand, opt.Value.([]byte), opt.Usage)
+			case []string:
+				flags.StringSliceP(opt.FlagName, opt.Shorthand, opt.Value.([]string), opt.Usage)
+			case map[string]string:
+				flags.StringToStringP(opt.FlagName, opt.Shorthand, opt.Value.(map[string]string), opt.Usage)
 			default:
 				return fmt.Errorf("invalid flag value provided for option %s", opt.FlagName)
 			}
@@ -47,9 +52,9 @@ func defineFlags(configOptions []ConfigOption) error {
 // defineWriteFlag defines the flag that can be used to write the project
 // configuration to the path supplied via the flag value. If an empty path
 // is supplied, the working directory is used.
-func defineWriteFlags() *string {
+func defineWriteFlags(flags *pflag.FlagSet) *string {
 	// Do not use a shorthand option to minimize programmer limitations.
-	return pflag.String(writeFlagName(), "",
+	return flags.String(writeFlagName(), "",
 		// Helps with formatting to the console.
 		`If supplied, the project configuration is written at the specified
 location, and returns an error if the operation is not possible. If 
@@ -67,16 +72,76 @@ configured)`)
 // config file with the name and type specified via WithConfigName and
 // WithConfigType, or the default values if those were not set. Otherwise,
 // if the location is a file, it will try to read the config from that file.
-func defineReadFlag() *string {
+// If the location is "-", the config is read from standard input.
+func defineReadFlag(flags *pflag.FlagSet) *string {
 	// Do not use a shorthand option to minimize programmer limitations.
-	return pflag.String(readFlagName(), "",
+	return flags.String(readFlagName(), "",
 		// Helps with formatting to the console.
 		`If supplied, the parser attempts to read the config from that specified
 location, and returns an error if no config file is found there. If empty,
 uses the working directory. If the location is a file, the parser tries to
 read that file. If the location is a directory, the parser will attempt to 
 read from a file inside that directory, with the configured named and type 
-(or the default values if not configured)`)
+(or the default values if not configured). If "-", the config is read from
+standard input.`)
+}
+
+// defineForceFlag defines the flag that allows the write flag to overwrite an
+// existing configuration file.
+func defineForceFlag(flags *pflag.FlagSet) *bool {
+	// Do not use a shorthand option to minimize programmer limitations.
+	return flags.Bool(forceFlagName(), false,
+		"If supplied, --"+writeFlagName()+" overwrites an existing config file.")
+}
+
+func forceFlagName() string {
+	return "force"
+}
+
+// defineWriteModeFlag defines the flag that selects which values are written
+// via the write flag.
+func defineWriteModeFlag(flags *pflag.FlagSet, mode WriteMode) *string {
+	// Do not use a shorthand option to minimize programmer limitations.
+	return flags.String(writeModeFlagName(), string(mode),
+		// Helps with formatting to the console.
+		`Selects the values written via --`+writeFlagName()+`: "all" writes every
+value, "overlay" only the values that differ from the defaults, and
+"flags" only the values of the flags supplied on the command line.`)
+}
+
+func writeModeFlagName() string {
+	return "write-mode"
+}
+
+// defineDiffFlag defines the flag that can be used to display the changes
+// between the current configuration and the configuration obtained by
+// reading the config file supplied via the flag value instead.
+func defineDiffFlag(flags *pflag.FlagSet) *string {
+	// Do not use a shorthand option to minimize programmer limitations.
+	return flags.String(diffFlagName(), "",
+		// Helps with formatting to the console.
+		`If supplied, the parser displays the keys that would be added, removed
+or changed if the config was read from the specified file instead of the
+current config file, and returns without creating the config.`)
+}
+
+// defineConfigTypeFlag defines the flag that can be used to set the type of
+// the config files read or written via flags, regardless of their extension.
+func defineConfigTypeFlag(flags *pflag.FlagSet) *string {
+	// Do not use a shorthand option to minimize programmer limitations.
+	return flags.String(configTypeFlagName(), "",
+		// Helps with formatting to the console.
+		`If supplied, the config files read via --`+readFlagName()+` and written via
+--`+writeFlagName()+` have the specified type (e.g. yaml, json or toml),
+instead of the type inferred from their extension.`)
+}
+
+func configTypeFlagName() string {
+	return "config-type"
+}
+
+func diffFlagName() string {
+	return "diff-config"
 }
 
 func writeFlagName() string {
@@ -0,0 +1,132 @@
+package configer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// stdinPath is the path supplied to the read flag to read the config from
+// standard input, and stdinName is the name of the source.
+const (
+	stdinPath = "-"
+	stdinName = "standard input"
+)
+
+var (
+	tomlTableRegexp = regexp.MustCompile(`^\[\[?[\w.\-" ]+\]\]?$`)
+	tomlKeyRegexp   = regexp.MustCompile(`^[\w.\-"]+\s*=`)
+	yamlKeyRegexp   = regexp.MustCompile(`^[\w.\-"']+\s*:(\s|$)`)
+)
+
+// fileFormat returns the type of the config file at filename, which decides
+// the decoder used to read it and the encoder used to write it. The type
+// inferred from the file extension takes precedence. For files without a
+// known extension, the type is inferred from content if sniffing is enabled
+// and content is not nil, otherwise the type set via WithConfigType is used.
+func (p *configParser) fileFormat(filename string, content []byte) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	if isSupportedFormat(ext) {
+		return ext
+	}
+	if p.sniffFormat && content != nil {
+		if format := sniffFormat(content); format != "" {
+			return format
+		}
+	}
+	return p.configExtension()
+}
+
+// flagFileFormat returns the type of a config file read or written via flags,
+// for which the type supplied via the config type flag takes precedence.
+func (p *configParser) flagFileFormat(filename string, content []byte) string {
+	if p.formatOverride != "" {
+		return p.formatOverride
+	}
+	return p.fileFormat(filename, content)
+}
+
+// readConfigFile reads the values from the config file at filename, using
+// the decoder associated with its type, and resolves its include directives.
+func (p *configParser) readConfigFile(filename string) (map[string]any, error) {
+	return p.readConfigFileAs(filename, p.fileFormat)
+}
+
+// readFlagConfigFile reads the config file supplied via the read flag, whose
+// type may be set via the config type flag.
+func (p *configParser) readFlagConfigFile(filename string) (map[string]any, error) {
+	return p.readConfigFileAs(filename, p.flagFileFormat)
+}
+
+// readConfigFileAs reads the config file at filename, with the type returned
+// by fileFormat.
+func (p *configParser) readConfigFileAs(filename string, fileFormat func(filename string, content []byte) string) (map[string]any, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not open config file: %w", err)
+	}
+	format := fileFormat(filename, content)
+	values, err := readConfigValues(bytes.NewReader(content), format)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config from file %s as %s: %w", filename, format, err)
+	}
+	return p.resolveIncludes(filename, values)
+}
+
+// readConfigStdin reads the values of a config file supplied via standard
+// input. Since there is no file extension, the type is taken from the config
+// type flag or WithConfigType, unless the content was sniffed. Relative include
+// paths are resolved from the working directory.
+func (p *configParser) readConfigStdin(content []byte) (map[string]any, error) {
+	format := p.flagFileFormat("", content)
+	values, err := readConfigValues(bytes.NewReader(content), format)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config from %s as %s: %w", stdinName, format, err)
+	}
+	return p.resolveIncludes(stdinPath, values)
+}
+
+// sniffFormat attempts to infer the type of a config file from its content.
+// It returns an empty string if the type could not be inferred.
+func sniffFormat(content []byte) string {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 {
+		return ""
+	}
+	if trimmed[0] == '{' && json.Valid(trimmed) {
+		return "json"
+	}
+
+	// The first line which is not a comment decides the type.
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+		switch {
+		case tomlTableRegexp.MatchString(line), tomlKeyRegexp.MatchString(line):
+			return "toml"
+		case yamlKeyRegexp.MatchString(line), strings.HasPrefix(line, "- "):
+			return "yaml"
+		}
+		return ""
+	}
+	return ""
+}
+
+func isSupportedFormat(format string) bool {
+	for _, ext := range viper.SupportedExts {
+		if format == ext {
+			return true
+		}
+	}
+	return false
+}
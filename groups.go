@@ -0,0 +1,111 @@
+package configer
+
+import (
+	"fmt"
+	"strings"
+)
+
+type groupKind int
+
+const (
+	// All keys of the group must be set together, or none of them.
+	requiredTogether groupKind = iota
+	// At most one key of the group may be set.
+	mutuallyExclusive
+	// Exactly one set of keys of the group must be set, completely.
+	exactlyOneOf
+)
+
+// optionGroup describes a relationship between config options. Each set
+// holds the config keys of the options that must be set together.
+type optionGroup struct {
+	kind groupKind
+	sets [][]string
+}
+
+// check verifies that the options of the group satisfy the group's
+// constraint, using isSet to determine whether a config key was set.
+func (g optionGroup) check(isSet func(key string) bool) error {
+	switch g.kind {
+	case requiredTogether:
+		set, unset := partition(g.sets[0], isSet)
+		if len(set) != 0 && len(unset) != 0 {
+			return fmt.Errorf("options %s must be set together, but %s %s not set", g.describe(), strings.Join(unset, ", "), verb(unset))
+		}
+
+	case mutuallyExclusive:
+		set, _ := partition(g.sets[0], isSet)
+		if len(set) > 1 {
+			return fmt.Errorf("options %s are mutually exclusive, but %s are set", g.describe(), strings.Join(set, ", "))
+		}
+
+	case exactlyOneOf:
+		var used []string
+		for _, keys := range g.sets {
+			set, unset := partition(keys, isSet)
+			if len(set) == 0 {
+				continue
+			}
+			if len(unset) != 0 {
+				return fmt.Errorf("options %s must be set together, but %s %s not set", strings.Join(keys, " and "), strings.Join(unset, ", "), verb(unset))
+			}
+			used = append(used, strings.Join(keys, " and "))
+		}
+		if len(used) == 0 {
+			return fmt.Errorf("exactly one of %s must be set, but none is", g.describe())
+		}
+		if len(used) > 1 {
+			return fmt.Errorf("exactly one of %s must be set, but %s are set", g.describe(), strings.Join(used, "; "))
+		}
+	}
+	return nil
+}
+
+// describe returns a human readable description of the group's keys.
+func (g optionGroup) describe() string {
+	if g.kind != exactlyOneOf {
+		return strings.Join(g.sets[0], ", ")
+	}
+
+	alternatives := make([]string, 0, len(g.sets))
+	for _, keys := range g.sets {
+		if len(keys) == 1 {
+			alternatives = append(alternatives, keys[0])
+			continue
+		}
+		alternatives = append(alternatives, "("+strings.Join(keys, " and ")+")")
+	}
+	return strings.Join(alternatives, ", ")
+}
+
+// usage describes the group's constraint in the usage message.
+func (g optionGroup) usage() string {
+	switch g.kind {
+	case requiredTogether:
+		return "must be set together: " + g.describe()
+	case mutuallyExclusive:
+		return "mutually exclusive: " + g.describe()
+	default:
+		return "exactly one of: " + g.describe()
+	}
+}
+
+// partition splits the keys into the ones that were set and the ones that
+// were not.
+func partition(keys []string, isSet func(key string) bool) (set, unset []string) {
+	for _, key := range keys {
+		if isSet(key) {
+			set = append(set, key)
+		} else {
+			unset = append(unset, key)
+		}
+	}
+	return set, unset
+}
+
+func verb(keys []string) string {
+	if len(keys) == 1 {
+		return "is"
+	}
+	return "are"
+}
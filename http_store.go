@@ -0,0 +1,123 @@
+package configer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPStore is a RemoteStore that fetches configuration from a key/value store
+// exposing a Consul-style JSON API. A GET request to URL must return the
+// entries as a list of objects with base64 encoded values, e.g.
+//
+//	[{"Key": "myapp/server/port", "Value": "ODA4MA=="}]
+//
+// Changes are detected through the X-Consul-Index header, which enables long
+// polling via the index and wait query parameters, or otherwise through the
+// ETag header, via If-None-Match requests.
+type HTTPStore struct {
+	// The URL the entries are fetched from, e.g.
+	// http://localhost:8500/v1/kv/myapp?recurse=true
+	URL string
+	// The prefix removed from every key, e.g. "myapp/". May be empty.
+	Prefix string
+	// The maximum duration a long polling request blocks for. Defaults to 5
+	// minutes.
+	Wait time.Duration
+	// The client used to send requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+const consulIndexHeader = "X-Consul-Index"
+
+// List implements RemoteStore.
+func (s *HTTPStore) List(ctx context.Context, index string) ([]KVPair, string, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid store url %s: %w", s.URL, err)
+	}
+
+	var etag bool
+	if index != "" {
+		// Indexes obtained from ETag headers are quoted.
+		etag = strings.HasPrefix(index, `"`) || strings.HasPrefix(index, `W/"`)
+		if !etag {
+			wait := s.Wait
+			if wait == 0 {
+				wait = 5 * time.Minute
+			}
+			query := u.Query()
+			query.Set("index", index)
+			query.Set("wait", wait.String())
+			u.RawQuery = query.Encode()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not create request: %w", err)
+	}
+	if etag {
+		req.Header.Set("If-None-Match", index)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	newIndex := resp.Header.Get(consulIndexHeader)
+	if newIndex == "" {
+		newIndex = resp.Header.Get("ETag")
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, index, ErrNotModified
+	// Consul returns 404 if there are no keys under the prefix.
+	case http.StatusNotFound:
+		return nil, newIndex, nil
+	case http.StatusOK:
+	default:
+		return nil, "", fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, s.URL)
+	}
+
+	// Blocking queries return the same index once the wait time expires.
+	if index != "" && newIndex == index {
+		return nil, index, ErrNotModified
+	}
+
+	var entries []struct {
+		Key   string
+		Value []byte
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, "", fmt.Errorf("could not decode entries from %s: %w", s.URL, err)
+	}
+
+	pairs := make([]KVPair, 0, len(entries))
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Key, s.Prefix) {
+			continue
+		}
+		pairs = append(pairs, KVPair{
+			Key:   strings.TrimPrefix(e.Key, s.Prefix),
+			Value: e.Value,
+		})
+	}
+	return pairs, newIndex, nil
+}
+
+// String returns the URL of the store.
+func (s *HTTPStore) String() string {
+	return s.URL
+}
@@ -0,0 +1,156 @@
+package configer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Keys of the include directives. The include key is only recognized at the
+// top level of a file, while the nested include key is recognized at any
+// level.
+const (
+	includeKey       = "include"
+	nestedIncludeKey = "$include"
+)
+
+// resolveIncludes replaces the include directives in the values read from
+// filename with the values of the included files, if includes are enabled.
+func (p *configParser) resolveIncludes(filename string, values map[string]any) (map[string]any, error) {
+	if !p.includes {
+		return values, nil
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve path of %s: %w", filename, err)
+	}
+	return p.includeValues(filepath.Dir(abs), values, []string{abs}, includeKey, nestedIncludeKey)
+}
+
+// includeValues resolves the include directives found at keys in values, and
+// the nested include directives of all sub-trees. The included files are
+// merged in order, and the values next to the directive take precedence over
+// the included ones. Relative paths are resolved from dir. The chain holds
+// the files being included, to detect cycles.
+func (p *configParser) includeValues(dir string, values map[string]any, chain []string, keys ...string) (map[string]any, error) {
+	for key, value := range values {
+		nested, ok := toStringMap(value)
+		if !ok {
+			continue
+		}
+		resolved, err := p.includeValues(dir, nested, chain, nestedIncludeKey)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = resolved
+	}
+
+	var directives []any
+	for _, key := range keys {
+		if directive, ok := values[key]; ok {
+			directives = append(directives, directive)
+			delete(values, key)
+		}
+	}
+	if len(directives) == 0 {
+		return values, nil
+	}
+
+	merged := make(map[string]any)
+	for _, directive := range directives {
+		patterns, err := includePatterns(directive)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include directive in %s: %w", chain[len(chain)-1], err)
+		}
+		for _, pattern := range patterns {
+			files, err := includedFiles(dir, pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid include directive in %s: %w", chain[len(chain)-1], err)
+			}
+			for _, file := range files {
+				included, err := p.readIncludedFile(file, chain)
+				if err != nil {
+					return nil, err
+				}
+				mergeValues(merged, included)
+			}
+		}
+	}
+	mergeValues(merged, values)
+	return merged, nil
+}
+
+// readIncludedFile reads the values of an included file, and resolves its own
+// include directives.
+func (p *configParser) readIncludedFile(file string, chain []string) (map[string]any, error) {
+	for _, including := range chain {
+		if including == file {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(chain, file), " -> "))
+		}
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read included file %s: %w", file, err)
+	}
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(file), "."))
+	if !isSupportedFormat(format) {
+		format = p.configExtension()
+	}
+	values, err := readConfigValues(bytes.NewReader(content), format)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse included file %s: %w", file, err)
+	}
+	p.log.Printf("[configer info] included config file %s from %s\n", file, chain[len(chain)-1])
+
+	// Copy the chain, since it is shared by the files included next to each
+	// other.
+	next := make([]string, len(chain), len(chain)+1)
+	copy(next, chain)
+	return p.includeValues(filepath.Dir(file), values, append(next, file), includeKey, nestedIncludeKey)
+}
+
+// includePatterns returns the paths referenced by an include directive, which
+// is either a single path or a list of paths.
+func includePatterns(directive any) ([]string, error) {
+	switch d := directive.(type) {
+	case string:
+		return []string{d}, nil
+	case []any:
+		patterns := make([]string, 0, len(d))
+		for _, pattern := range d {
+			s, ok := pattern.(string)
+			if !ok {
+				return nil, fmt.Errorf("included path %v is not a string", pattern)
+			}
+			patterns = append(patterns, s)
+		}
+		return patterns, nil
+	case []string:
+		return d, nil
+	}
+	return nil, fmt.Errorf("expected a path or a list of paths, got %v", directive)
+}
+
+// includedFiles returns the absolute paths of the files matching the pattern,
+// in lexical order. Patterns without wildcards must reference an existing
+// file, while patterns with wildcards may match no files.
+func includedFiles(dir, pattern string) ([]string, error) {
+	pattern = expandPath(pattern)
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	if !strings.ContainsAny(pattern, `*?[\`) {
+		return []string{pattern}, nil
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
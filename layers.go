@@ -0,0 +1,85 @@
+package configer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Priorities of the built-in sources stored as layers. Sources with a higher
+// priority take precedence over the ones with a lower priority.
+//
+// Regardless of their priority, all sources take precedence over the default
+// values and are overwritten by environment variables and flags.
+const (
+	// PriorityRemote is the priority of the stores added via WithRemoteStore.
+	PriorityRemote = 10
+	// PriorityFile is the priority of the configuration file. Drop-in
+	// fragments have the same priority, and are merged after the file.
+	PriorityFile = 20
+)
+
+// layer holds the configuration values supplied by a single source. Default
+// values, environment variables and flags are not stored as layers, since
+// viper handles those directly.
+type layer struct {
+	name     string
+	priority int
+	values   map[string]any
+	// Whether the layer holds the values of the configuration file, which
+	// are migrated to the current schema version.
+	configFile bool
+}
+
+// addLayer stores the values supplied by a source, to be merged with the
+// values of the other sources.
+func (p *configParser) addLayer(name string, priority int, values map[string]any) {
+	p.layers = append(p.layers, layer{
+		name:     name,
+		priority: priority,
+		values:   values,
+	})
+}
+
+// addConfigFileLayer stores the values of the configuration file, read from
+// the path or found via the search paths.
+func (p *configParser) addConfigFileLayer(path string, values map[string]any) {
+	p.layers = append(p.layers, layer{
+		name:       path,
+		priority:   PriorityFile,
+		values:     values,
+		configFile: true,
+	})
+}
+
+// mergedLayers returns the values of all layers merged according to their
+// priority. Layers with equal priority are merged in the order they were
+// added.
+func (p *configParser) mergedLayers() map[string]any {
+	layers := make([]layer, len(p.layers))
+	copy(layers, p.layers)
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i].priority < layers[j].priority
+	})
+
+	merged := make(map[string]any)
+	for _, l := range layers {
+		mergeValues(merged, l.values)
+	}
+	return merged
+}
+
+// mergeLayers merges the values of all layers into the parser's config.
+func (p *configParser) mergeLayers() error {
+	if err := p.migrateLayers(); err != nil {
+		return err
+	}
+
+	merged := p.mergedLayers()
+	if err := p.decryptValues(merged); err != nil {
+		return err
+	}
+	if err := p.viper.MergeConfigMap(merged); err != nil {
+		return fmt.Errorf("could not merge configuration sources: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package configer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Loader loads the project configuration from a flag set which is parsed by
+// the caller, such as the flag set of a cobra command. Use NewConfig instead
+// if the project uses the command-line flags.
+type Loader struct {
+	parser        *configParser
+	appOptions    []ConfigOption
+	parserOptions []ParserOption
+
+	readFlag  *string
+	writeFlag *string
+	forceFlag *bool
+	// The write mode supplied on the command line.
+	writeModeFlag *string
+	diffFlag      *string
+	typeFlag      *string
+
+	// The config read from standard input via the read flag.
+	stdin []byte
+}
+
+// RegisterFlags defines the flags of the config options on the provided flag
+// set, and returns a Loader which can load the configuration once the flag
+// set was parsed. It does not parse the flag set.
+func RegisterFlags(flags *pflag.FlagSet, appOptions []ConfigOption, parserOptions ...ParserOption) (*Loader, error) {
+
+	parser := newConfiguredParser(flags, parserOptions...)
+
+	// Define the flags after applying the options to allow defining special
+	// flags as well.
+	err := defineFlags(flags, appOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to define flags: %w", err)
+	}
+
+	loader := &Loader{
+		parser:        parser,
+		appOptions:    appOptions,
+		parserOptions: parserOptions,
+	}
+	if parser.readFlag {
+		loader.readFlag = defineReadFlag(flags)
+	}
+	if parser.writeFlag {
+		// These flags are only reserved if the write flag is enabled.
+		for _, name := range []string{forceFlagName(), writeModeFlagName()} {
+			if flags.Lookup(name) != nil {
+				return nil, fmt.Errorf("cannot use reserved flag name: %s", name)
+			}
+		}
+		loader.writeFlag = defineWriteFlags(flags)
+		loader.forceFlag = defineForceFlag(flags)
+		loader.writeModeFlag = defineWriteModeFlag(flags, parser.writeMode)
+	}
+	if parser.diffFlag {
+		loader.diffFlag = defineDiffFlag(flags)
+	}
+	if parser.configTypeFlag {
+		loader.typeFlag = defineConfigTypeFlag(flags)
+	}
+	if parser.usage {
+		flags.Usage = func() {
+			loader.WriteUsage(os.Stderr)
+		}
+	}
+	return loader, nil
+}
+
+// newConfiguredParser creates a parser with the provided options, which reads
+// the flags from the provided flag set.
+func newConfiguredParser(flags *pflag.FlagSet, parserOptions ...ParserOption) *configParser {
+	parser := newParser()
+	parser.setDefaultParserOptions()
+	parser.applyOptions(parserOptions...)
+	parser.flags = flags
+
+	// Do not log anything to package users.
+	if parser.suppressLogs {
+		parser.log = log.New(io.Discard, "", 0)
+	}
+	return parser
+}
+
+// Load reads the configuration from all sources and unmarshals it to the
+// provided struct. It must be called after the flag set provided to
+// RegisterFlags was parsed.
+func (l *Loader) Load(configStruct interface{}) error {
+	if err := l.load(); err != nil {
+		return err
+	}
+
+	if err := l.parser.unmarshal(&configStruct); err != nil {
+		return err
+	}
+	l.parser.publishFingerprint(l.appOptions)
+
+	// Only watch for changes once the config was created successfully.
+	l.parser.watchSources()
+	return nil
+}
+
+// LoadSnapshot reads the configuration from all sources and returns a
+// read-only snapshot of it. It must be called after the flag set provided to
+// RegisterFlags was parsed.
+func (l *Loader) LoadSnapshot() (*Snapshot, error) {
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+
+	snapshot := l.parser.snapshot(l.appOptions)
+	l.parser.publishFingerprint(l.appOptions)
+
+	// Only watch for changes once the config was created successfully.
+	l.parser.watchSources()
+	return snapshot, nil
+}
+
+// load reads the configuration from all sources, and validates it.
+func (l *Loader) load() error {
+	parser := l.parser
+	readFlag, writeFlag := l.readFlag, l.writeFlag
+
+	err := parser.setDefaultValues(l.appOptions)
+	if err != nil {
+		return fmt.Errorf("could not set default values: %w", err)
+	}
+
+	err = parser.bindEnvVars(l.appOptions)
+	if err != nil {
+		return fmt.Errorf("could not bind environment variables: %w", err)
+	}
+
+	err = parser.readIndexedEnvVars(l.appOptions)
+	if err != nil {
+		return fmt.Errorf("could not read environment variables: %w", err)
+	}
+
+	err = parser.setDefaultFileValues()
+	if err != nil {
+		return fmt.Errorf("could not set default file values: %w", err)
+	}
+
+	if l.typeFlag != nil && parser.flags.Lookup(configTypeFlagName()).Changed {
+		format := strings.ToLower(*l.typeFlag)
+		if !isSupportedFormat(format) {
+			return fmt.Errorf("unsupported config type %s", *l.typeFlag)
+		}
+		parser.formatOverride = format
+	}
+
+	readFlagChanged := readFlag != nil && parser.flags.Lookup(readFlagName()).Changed
+
+	// Config content was supplied via standard input.
+	if readFlagChanged && *readFlag == stdinPath {
+		// Standard input can only be read once, so the content is kept for
+		// reloads and candidate configs.
+		if l.stdin == nil {
+			content, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("could not read config from %s: %w", stdinName, err)
+			}
+			l.stdin = content
+		}
+		values, err := parser.readConfigStdin(l.stdin)
+		if err != nil {
+			return err
+		}
+		parser.addLayer(stdinName, PriorityFile, values)
+		parser.log.Println("[configer info] read config from standard input")
+
+		// Config path was supplied explicitly via flag.
+	} else if readFlagChanged {
+		if *readFlag == "" {
+			*readFlag = "."
+		}
+		*readFlag = expandPath(*readFlag)
+		stat, err := os.Stat(*readFlag)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("no config file found at location %s", *readFlag)
+			}
+			return fmt.Errorf("could not retrieve stats for %s: %w", *readFlag, err)
+		}
+
+		configpath := *readFlag
+
+		// If the path supplied is a directory, search for the config file
+		// inside it.
+		if stat.IsDir() {
+			configpath, err = parser.findConfigFile([]string{*readFlag})
+			if err != nil {
+				return err
+			}
+			if configpath == "" {
+				return fmt.Errorf("no config file found in directory %s", *readFlag)
+			}
+		}
+
+		values, err := parser.readFlagConfigFile(configpath)
+		if err != nil {
+			return err
+		}
+		parser.addConfigFileLayer(configpath, values)
+		// TODO: use absolute path?
+		parser.log.Printf("[configer info] read config at %s\n", configpath)
+
+		// Config file was set via WithConfigFile.
+	} else if parser.configFile != "" {
+		values, err := parser.readConfigFile(parser.configFile)
+		if err != nil {
+			return fmt.Errorf("could not read config: %w", err)
+		}
+		parser.addConfigFileLayer(parser.configFile, values)
+		parser.log.Printf("[configer info] read config at %s\n", parser.configFile)
+
+		// No explicit config path set, use the values provided via
+		// WithConfigPath.
+	} else {
+		configpath, err := parser.findConfigFile(parser.configPaths)
+		if err != nil {
+			return fmt.Errorf("could not read config: %w", err)
+		}
+		if configpath == "" {
+			additional := ""
+			// Do not show this additional message unless they enable
+			// --write-config.
+			if writeFlag != nil {
+				additional += " Use --write-config to create it."
+			}
+			parser.log.Printf("[configer warn] Config file not found.%s\n", additional)
+		} else {
+			values, err := parser.readConfigFile(configpath)
+			if err != nil {
+				return fmt.Errorf("could not read config: %w", err)
+			}
+			parser.addConfigFileLayer(configpath, values)
+			parser.log.Printf("[configer info] read config at %s\n", configpath)
+		}
+	}
+
+	if err = parser.readDropInFiles(); err != nil {
+		return err
+	}
+
+	if err = parser.loadSources(context.Background()); err != nil {
+		return err
+	}
+
+	if err = parser.mergeLayers(); err != nil {
+		return err
+	}
+	parser.bindSettingsEnvVars()
+	if err = parser.decryptEnvValues(l.appOptions); err != nil {
+		return err
+	}
+
+	if writeFlag != nil && parser.flags.Lookup(writeFlagName()).Changed {
+		// Invalid configurations are never written, since they could not be
+		// read back, e.g. if required options are missing.
+		if err := parser.validate(l.appOptions); err != nil {
+			return err
+		}
+		parser.log.Println("[configer info] Writing configuration file.")
+
+		if *writeFlag == "" {
+			*writeFlag = "."
+		}
+		*writeFlag = expandPath(*writeFlag)
+
+		configpath := *writeFlag
+
+		stat, err := os.Stat(configpath)
+		switch {
+		case err == nil:
+			if stat.IsDir() {
+				configpath = path.Join(configpath, parser.configName)
+			}
+		// If the specified path doesn't exist as a file or directory, attempt
+		// to write as a file, unless it ends with a separator.
+		case os.IsNotExist(err):
+			if strings.HasSuffix(configpath, "/") {
+				err := os.MkdirAll(configpath, parser.writeDirMode())
+				if err != nil {
+					return fmt.Errorf("could not create directory %s provided via write flag: %w", configpath, err)
+				}
+				configpath = path.Join(configpath, parser.configName)
+			}
+		default:
+			return fmt.Errorf("could not get stats for path %s provided via write flag: %w", configpath, err)
+		}
+
+		mode := parser.writeMode
+		if l.writeModeFlag != nil && parser.flags.Lookup(writeModeFlagName()).Changed {
+			mode = WriteMode(*l.writeModeFlag)
+		}
+		settings, err := parser.writeSettings(mode, l.appOptions)
+		if err != nil {
+			return fmt.Errorf("could not prepare config for writing: %w", err)
+		}
+
+		force := l.forceFlag != nil && *l.forceFlag
+		if err := parser.writeConfig(configpath, settings, force); err != nil {
+			return fmt.Errorf("could not write config at path %s provided via write flag: %w", configpath, err)
+		}
+		parser.log.Printf("[configer info] writing config at %s\n", configpath)
+	}
+	if l.diffFlag != nil && parser.flags.Lookup(diffFlagName()).Changed {
+		return l.writeDiff(os.Stdout, *l.diffFlag)
+	}
+
+	return parser.validate(l.appOptions)
+}
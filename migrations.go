@@ -0,0 +1,122 @@
+package configer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// versionKey is the config key storing the schema version of a configuration
+// file.
+const versionKey = "version"
+
+// Migration transforms the values of a configuration file from a schema
+// version to the next one. Nested configuration keys are represented as
+// nested maps. MoveKey may be used to rename keys.
+type Migration func(values map[string]any) (map[string]any, error)
+
+// keyAlias maps a deprecated config key to the key that replaced it.
+type keyAlias struct {
+	oldKey string
+	newKey string
+}
+
+// MoveKey moves the value stored at the "." separated config key from to the
+// config key to, inside a nested configuration map. It does nothing if there
+// is no value stored at from.
+func MoveKey(values map[string]any, from, to string) {
+	value, ok := deleteNestedValue(values, from)
+	if !ok {
+		return
+	}
+	setNestedValue(values, to, value)
+}
+
+// migrateLayers brings the configuration file read by the parser to the
+// current schema version, and replaces the deprecated keys of every layer.
+// The values are copied first, since they may be owned by a custom source.
+func (p *configParser) migrateLayers() error {
+	for i, l := range p.layers {
+		values := copyValues(l.values)
+		if p.configVersion != 0 && l.configFile {
+			var err error
+			values, err = p.migrate(l.name, values)
+			if err != nil {
+				return err
+			}
+		}
+		p.replaceAliases(l.name, values)
+		p.layers[i].values = values
+	}
+	return nil
+}
+
+// migrate applies the registered migrations to the values of a configuration
+// file, starting from the version stored in the file. Files without a version
+// are considered to be at version 1.
+func (p *configParser) migrate(name string, values map[string]any) (map[string]any, error) {
+	version := 1
+	if raw, ok := values[versionKey]; ok {
+		v, err := toInt(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config version in %s: %w", name, err)
+		}
+		version = v
+	}
+
+	if version > p.configVersion {
+		return nil, fmt.Errorf("config version %d of %s is newer than the supported version %d", version, name, p.configVersion)
+	}
+
+	for ; version < p.configVersion; version++ {
+		migration, ok := p.migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration registered from config version %d to %d", version, version+1)
+		}
+
+		var err error
+		values, err = migration(values)
+		if err != nil {
+			return nil, fmt.Errorf("could not migrate %s from config version %d to %d: %w", name, version, version+1, err)
+		}
+		p.log.Printf("[configer info] migrated %s from config version %d to %d\n", name, version, version+1)
+	}
+
+	values[versionKey] = p.configVersion
+	return values, nil
+}
+
+// replaceAliases moves the values stored at deprecated keys to the keys that
+// replaced them, logging a warning for each.
+func (p *configParser) replaceAliases(name string, values map[string]any) {
+	for _, alias := range p.aliases {
+		value, ok := deleteNestedValue(values, alias.oldKey)
+		if !ok {
+			continue
+		}
+
+		if _, exists := getNestedValue(values, alias.newKey); exists {
+			p.log.Printf("[configer warn] config key %s in %s is deprecated and ignored, since %s is also set\n", alias.oldKey, name, alias.newKey)
+			continue
+		}
+		p.log.Printf("[configer warn] config key %s in %s is deprecated, use %s instead\n", alias.oldKey, name, alias.newKey)
+		setNestedValue(values, alias.newKey, value)
+	}
+}
+
+func toInt(value any) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		return int(v), nil
+	case string:
+		return strconv.Atoi(strings.TrimSpace(v))
+	}
+	return 0, fmt.Errorf("%v is not an integer", value)
+}
@@ -0,0 +1,91 @@
+package configer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadMigratedConfig(t *testing.T) {
+	f, dir := initFile(t, "test.yml", []byte("old:\n  numberr: 13\nstr: migrated\n"))
+	defer f.Close()
+
+	resetFlags(t)
+
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithConfigName("test"),
+		WithConfigType("yml"),
+		WithConfigPath(dir),
+		WithConfigVersion(2),
+		WithMigration(1, func(values map[string]any) (map[string]any, error) {
+			MoveKey(values, "old.numberr", "numberr")
+			return values, nil
+		}),
+		WithKeyAlias("str", "stringg"),
+		WithSupressLogs())
+
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+
+	checkExample1(t, ex, Example1{
+		Numberr:   13,
+		Stringg:   "migrated",
+		Booll:     false,
+		Durationn: 2 * time.Second,
+	})
+}
+
+func TestReadNewerConfigVersion(t *testing.T) {
+	f, dir := initFile(t, "test.yml", []byte("version: 3\n"))
+	defer f.Close()
+
+	resetFlags(t)
+
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithConfigName("test"),
+		WithConfigType("yml"),
+		WithConfigPath(dir),
+		WithConfigVersion(2),
+		WithSupressLogs())
+
+	if err == nil {
+		t.Fatalf("expected error for newer config version")
+	}
+}
+
+func TestMigrateOnlyConfigFile(t *testing.T) {
+	f, dir := initFile(t, "test.yml", []byte("version: 2\nnumberr: 13\n"))
+	defer f.Close()
+
+	resetFlags(t)
+
+	// Neither the source nor its values are versioned, and the values
+	// belong to the source.
+	src := staticSource{"str": "source"}
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithConfigName("test"),
+		WithConfigType("yml"),
+		WithConfigPath(dir),
+		WithConfigVersion(2),
+		WithSource(src, PriorityFile),
+		WithKeyAlias("str", "stringg"),
+		WithSupressLogs())
+
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+
+	checkExample1(t, ex, Example1{
+		Numberr:   13,
+		Stringg:   "source",
+		Booll:     false,
+		Durationn: 2 * time.Second,
+	})
+	if !reflect.DeepEqual(src, staticSource{"str": "source"}) {
+		t.Fatalf("values of the source were modified: %v", src)
+	}
+}
@@ -0,0 +1,27 @@
+package configer
+
+type configCandidatesOption []string
+
+func (opt configCandidatesOption) apply(parser *configParser) {
+	parser.configCandidateNames = append(parser.configCandidateNames, opt...)
+}
+
+// WithConfigCandidates allows specifying multiple names of the configuration
+// file the parser looks for, including the extension (e.g. "config.yaml",
+// "config.yml", "app.toml"). The type of each file is inferred from its
+// extension.
+//
+// The config paths are searched in the order they were added, and each path
+// is searched for the candidates in the order they were supplied. The first
+// path containing a candidate is used. If a path contains more than one
+// candidate, the parser returns an error, since the choice is ambiguous.
+//
+// Files written via --write-config in a directory are still named after the
+// values set via WithConfigName and WithConfigType.
+//
+// By default, the parser searches for the name set via WithConfigName with
+// every supported extension, and without extension, using the first file
+// found in a path.
+func WithConfigCandidates(names ...string) configCandidatesOption {
+	return configCandidatesOption(names)
+}
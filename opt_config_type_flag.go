@@ -0,0 +1,17 @@
+package configer
+
+type configTypeFlagOption bool
+
+func (opt configTypeFlagOption) apply(parser *configParser) {
+	parser.configTypeFlag = bool(opt)
+}
+
+// WithConfigTypeFlag defines a flag that can be used to explicitly set the
+// type of the config files read via --read-config and written via
+// --write-config, regardless of their extension. Config files found via the
+// search paths or set via WithConfigFile are not affected.
+//
+// By default, this flag will not be defined.
+func WithConfigTypeFlag() configTypeFlagOption {
+	return configTypeFlagOption(true)
+}
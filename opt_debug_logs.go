@@ -0,0 +1,16 @@
+package configer
+
+type debugLogsOption bool
+
+func (opt debugLogsOption) apply(parser *configParser) {
+	parser.debugLogs = bool(opt)
+}
+
+// WithDebugLogs enables additional logs that help troubleshooting the parser,
+// such as every path searched for the config file. It has no effect if logs
+// are suppressed.
+//
+// By default, debug logs are not active.
+func WithDebugLogs() debugLogsOption {
+	return debugLogsOption(true)
+}
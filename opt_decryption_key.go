@@ -0,0 +1,37 @@
+package configer
+
+type decryptionKeyFileOption string
+
+func (opt decryptionKeyFileOption) apply(parser *configParser) {
+	parser.decryptionKeyFile = string(opt)
+}
+
+// WithDecryptionKeyFile allows specifying a file containing the key used to
+// decrypt the encrypted values of the configuration file and the other
+// sources. The key must be base64 encoded, and may be generated via
+// GenerateKey. Values can be encrypted via EncryptValue, and have the format
+//
+//	password: ENC[AES256_GCM,data:...,iv:...,tag:...]
+//
+// Decrypted values are never written in plaintext via --write-config.
+//
+// This option is not set by default.
+func WithDecryptionKeyFile(path string) decryptionKeyFileOption {
+	return decryptionKeyFileOption(path)
+}
+
+type decryptionKeyEnvOption string
+
+func (opt decryptionKeyEnvOption) apply(parser *configParser) {
+	parser.decryptionKeyEnv = string(opt)
+}
+
+// WithDecryptionKeyEnv allows specifying an environment variable containing
+// the key used to decrypt the encrypted values of the configuration file and
+// the other sources. If the key is also provided via WithDecryptionKeyFile,
+// the environment variable takes precedence when it is set.
+//
+// This option is not set by default.
+func WithDecryptionKeyEnv(name string) decryptionKeyEnvOption {
+	return decryptionKeyEnvOption(name)
+}
@@ -0,0 +1,38 @@
+package configer
+
+import "io/fs"
+
+type defaultConfigFileOption struct {
+	fsys fs.FS
+	name string
+}
+
+func (opt defaultConfigFileOption) apply(parser *configParser) {
+	parser.defaultFileFS = opt.fsys
+	parser.defaultFileName = opt.name
+}
+
+// WithDefaultConfigFile allows providing a configuration file, usually
+// embedded in the binary via the go:embed directive, whose values are used as
+// defaults for the project. The values of this file take precedence over the
+// values of the config options, and are overwritten by every other source.
+// Nested keys are merged with the ones read from the configuration file, and
+// the file's values are included in the file written via --write-config.
+//
+// The file type is deduced from the file extension. If the file has no
+// extension, the type specified via WithConfigType is used.
+//
+// e.g.
+//
+//	//go:embed config.default.yml
+//	var defaults embed.FS
+//
+//	configer.WithDefaultConfigFile(defaults, "config.default.yml")
+//
+// This option is not set by default.
+func WithDefaultConfigFile(fsys fs.FS, name string) defaultConfigFileOption {
+	return defaultConfigFileOption{
+		fsys: fsys,
+		name: name,
+	}
+}
@@ -0,0 +1,21 @@
+package configer
+
+type diffFlagOption bool
+
+func (opt diffFlagOption) apply(parser *configParser) {
+	parser.diffFlag = bool(opt)
+}
+
+// WithDiffFlag defines a flag that can be used to display the config keys
+// that would be added, removed or changed if the configuration was read from
+// the file supplied via the flag, instead of the current config file. Each
+// change is displayed together with the sources of the values, and secret
+// values are redacted.
+//
+// When the flag is supplied, the config is not created and ErrConfigDiffed is
+// returned.
+//
+// By default, this flag will not be defined.
+func WithDiffFlag() diffFlagOption {
+	return diffFlagOption(true)
+}
@@ -0,0 +1,23 @@
+package configer
+
+type dropInDirOption string
+
+func (opt dropInDirOption) apply(parser *configParser) {
+	parser.dropInDirs = append(parser.dropInDirs, expandPath(string(opt)))
+}
+
+// WithDropInDir allows specifying a directory containing config fragments,
+// such as /etc/<app>/conf.d, which are merged on top of the configuration
+// file. The fragments are merged in lexical order of their file names, and
+// may have any supported type, inferred from their extension. Hidden files,
+// subdirectories and files with other extensions are ignored. If the
+// directory doesn't exist, it is ignored as well.
+//
+// The fragment that supplied a value is reported as its source, e.g. by
+// Snapshot.Source. Multiple directories are merged in the order they were
+// added.
+//
+// By default, no drop-in directory is read.
+func WithDropInDir(dir string) dropInDirOption {
+	return dropInDirOption(dir)
+}
@@ -5,6 +5,7 @@ import "strings"
 type envKeyReplacerOption string
 
 func (r envKeyReplacerOption) apply(parser *configParser) {
+	parser.envKeyReplacer = string(r)
 	replacer := strings.NewReplacer(".", string(r))
 	parser.viper.SetEnvKeyReplacer(replacer)
 }
@@ -24,6 +25,7 @@ func WithEnvKeyReplacer(replacer string) envKeyReplacerOption {
 type envPrefixOptions string
 
 func (p envPrefixOptions) apply(parser *configParser) {
+	parser.envPrefix = string(p)
 	parser.viper.SetEnvPrefix(string(p))
 }
 
@@ -3,12 +3,14 @@ package configer
 type configFileOption string
 
 func (opt configFileOption) apply(parser *configParser) {
-	parser.viper.SetConfigFile(string(opt))
+	parser.configFile = expandPath(string(opt))
 }
 
 // WithConfigFile allows providing a specific configuration file for the parser.
 // This will overwrite all the options that have been specified through
-// WithConfigPath, including the default ones.
+// WithConfigPath, including the default ones. The type of the file is
+// inferred from its extension. A leading "~" and environment variables are
+// expanded.
 //
 // This option is not set by default.
 func WithConfigFile(configFile string) configFileOption {
@@ -0,0 +1,27 @@
+package configer
+
+type fingerprintOption struct {
+	expvarName     string
+	excludeSecrets bool
+}
+
+func (opt fingerprintOption) apply(parser *configParser) {
+	parser.fingerprint = true
+	parser.fingerprintVar = opt.expvarName
+	parser.fingerprintExcludeSecrets = opt.excludeSecrets
+}
+
+// WithFingerprint logs the fingerprint of the configuration once it was
+// created, and after every reload, which allows detecting replicas running
+// different configurations. If expvarName is not empty, the fingerprint is
+// also published as an expvar string variable with that name. If
+// excludeSecrets is set, secret values do not contribute to the fingerprint.
+// See Snapshot.Fingerprint for details.
+//
+// By default, the fingerprint is not published.
+func WithFingerprint(expvarName string, excludeSecrets bool) fingerprintOption {
+	return fingerprintOption{
+		expvarName:     expvarName,
+		excludeSecrets: excludeSecrets,
+	}
+}
@@ -0,0 +1,18 @@
+package configer
+
+type formatSniffingOption bool
+
+func (opt formatSniffingOption) apply(parser *configParser) {
+	parser.sniffFormat = bool(opt)
+}
+
+// WithFormatSniffing allows the parser to infer the type of config files
+// without a known extension from their content. Only json, toml and yaml
+// files are recognized. If the type cannot be inferred, the type set via
+// WithConfigType is used.
+//
+// By default, files without a known extension are read with the type set via
+// WithConfigType.
+func WithFormatSniffing() formatSniffingOption {
+	return formatSniffingOption(true)
+}
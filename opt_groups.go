@@ -0,0 +1,43 @@
+package configer
+
+type optionGroupOption optionGroup
+
+func (opt optionGroupOption) apply(parser *configParser) {
+	parser.groups = append(parser.groups, optionGroup(opt))
+}
+
+// WithRequiredTogether declares that the config options with the provided
+// keys must either all be set, or none of them, e.g. "tls.cert" and
+// "tls.key". An option counts as set if it was supplied via a flag, an
+// environment variable, the configuration file or another source.
+//
+// Constraints are checked after merging all sources, and are listed in the
+// usage message enabled via WithUsage.
+func WithRequiredTogether(keys ...string) optionGroupOption {
+	return optionGroupOption{
+		kind: requiredTogether,
+		sets: [][]string{keys},
+	}
+}
+
+// WithMutuallyExclusive declares that at most one of the config options with
+// the provided keys may be set, e.g. "auth.token" and "auth.token_file".
+func WithMutuallyExclusive(keys ...string) optionGroupOption {
+	return optionGroupOption{
+		kind: mutuallyExclusive,
+		sets: [][]string{keys},
+	}
+}
+
+// WithExactlyOneOf declares that exactly one of the provided sets of config
+// options must be set, and that the options of that set must all be set.
+//
+// e.g. requiring either "db.url", or both "db.host" and "db.port":
+//
+//	configer.WithExactlyOneOf([]string{"db.url"}, []string{"db.host", "db.port"})
+func WithExactlyOneOf(sets ...[]string) optionGroupOption {
+	return optionGroupOption{
+		kind: exactlyOneOf,
+		sets: sets,
+	}
+}
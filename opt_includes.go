@@ -0,0 +1,28 @@
+package configer
+
+type includesOption bool
+
+func (opt includesOption) apply(parser *configParser) {
+	parser.includes = bool(opt)
+}
+
+// WithIncludes enables include directives inside config files. An "include"
+// key at the top level of a file lists the files merged at the top level,
+// and an "$include" key at any level lists the files merged at the position
+// of the key:
+//
+//	include: [database.yml, features/*.yml]
+//	server:
+//	  tls:
+//	    $include: ./tls.yml
+//
+// A directive holds either a single path or a list of paths, which may
+// contain wildcards. Relative paths are resolved from the directory of the
+// including file. The included files are merged in order, and the values set
+// next to the directive take precedence over the included ones. Included
+// files may include other files, and include cycles are reported as errors.
+//
+// By default, include directives are not recognized.
+func WithIncludes() includesOption {
+	return includesOption(true)
+}
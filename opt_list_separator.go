@@ -0,0 +1,21 @@
+package configer
+
+type listSeparatorOption string
+
+func (opt listSeparatorOption) apply(parser *configParser) {
+	parser.listSeparator = string(opt)
+}
+
+// WithListSeparator allows specifying the separator used when decoding lists
+// and maps from strings, such as the values of environment variables.
+//
+// e.g. with the "," separator, DEMO_HOSTS="a,b" is decoded to a []string
+// with two elements, and DEMO_LABELS="k1=v1,k2=v2" to a map[string]string
+// with two keys. Values starting with "[" or "{" are decoded as JSON, and
+// lists may also be set via indexed variables, such as DEMO_HOSTS_0="a" or
+// DEMO_SERVERS_0_HOST="a" for a list of structs.
+//
+// By default, the parser uses the "," separator.
+func WithListSeparator(separator string) listSeparatorOption {
+	return listSeparatorOption(separator)
+}
@@ -0,0 +1,67 @@
+package configer
+
+type configVersionOption int
+
+func (opt configVersionOption) apply(parser *configParser) {
+	parser.configVersion = int(opt)
+}
+
+// WithConfigVersion enables versioning of the configuration file schema, and
+// sets the current version. The version of a file is stored at the "version"
+// key, and files without it are considered to be at version 1. Older files
+// are brought to the current version via the migrations registered with
+// WithMigration before their values are used, and files written via
+// --write-config have the current shape and version. Only the configuration
+// file is versioned: drop-in fragments, standard input and custom sources are
+// not migrated.
+//
+// By default, configuration files are not versioned.
+func WithConfigVersion(version int) configVersionOption {
+	return configVersionOption(version)
+}
+
+type migrationOption struct {
+	from      int
+	migration Migration
+}
+
+func (opt migrationOption) apply(parser *configParser) {
+	parser.migrations[opt.from] = opt.migration
+}
+
+// WithMigration registers the migration which transforms configuration files
+// from version from to version from+1. A migration must be registered for
+// every version older than the one set via WithConfigVersion.
+//
+// e.g. renaming "server.addr" to "server.address" in version 2:
+//
+//	configer.WithConfigVersion(2),
+//	configer.WithMigration(1, func(values map[string]any) (map[string]any, error) {
+//		configer.MoveKey(values, "server.addr", "server.address")
+//		return values, nil
+//	}),
+func WithMigration(from int, migration Migration) migrationOption {
+	return migrationOption{
+		from:      from,
+		migration: migration,
+	}
+}
+
+type keyAliasOption keyAlias
+
+func (opt keyAliasOption) apply(parser *configParser) {
+	parser.aliases = append(parser.aliases, keyAlias(opt))
+}
+
+// WithKeyAlias marks oldKey as a deprecated alias of newKey. Values set at
+// oldKey in the configuration file or the other sources are used for newKey,
+// and a warning naming both keys is logged. If both keys are set, the value
+// of newKey is used.
+//
+// This option is not set by default.
+func WithKeyAlias(oldKey, newKey string) keyAliasOption {
+	return keyAliasOption{
+		oldKey: oldKey,
+		newKey: newKey,
+	}
+}
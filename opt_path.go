@@ -3,12 +3,16 @@ package configer
 type configPathOption string
 
 func (opt configPathOption) apply(parser *configParser) {
-	parser.viper.AddConfigPath(string(opt))
+	parser.configPaths = append(parser.configPaths, expandPath(string(opt)))
 }
 
 // WithConfigPath allows specifying paths where the parser should search for
-// a configuration options file. The format of this configuration file may be
-// defined using the functions WithConfigType and WithConfigName.
+// a configuration options file. The paths are searched in the order they were
+// added. The name and format of this configuration file may be defined using
+// the functions WithConfigType and WithConfigName, or WithConfigCandidates.
+//
+// A leading "~" is expanded to the home directory, and environment variables
+// such as $HOME are expanded as well.
 //
 // By default, the parser searches for configuration files in the project
 // directory.
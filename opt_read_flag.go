@@ -12,10 +12,15 @@ func (opt readFlagOption) apply(parser *configParser) {
 //
 // If the specified location is a directory, the parser will search for a
 // config file with the name and type specified via WithConfigName and
-// WithConfigType, or the default values if those were not set. Otherwise,
-// if the location is a file, it will try to read the config from that file.
+// WithConfigType, or the default values if those were not set, or for the
+// names specified via WithConfigCandidates. Otherwise,
+// if the location is a file, it will try to read the config from that file,
+// using the decoder inferred from the file extension.
 // If no config file is found, the parser will throw an error.
 //
+// If the location is "-", the config is read from standard input, with the
+// type set via WithConfigType or the flag defined by WithConfigTypeFlag.
+//
 // By default, this flag will not be defined.
 func WithReadFlag() readFlagOption {
 	return readFlagOption(true)
@@ -0,0 +1,37 @@
+package configer
+
+type remoteStoreOption struct {
+	store   RemoteStore
+	options RemoteOptions
+}
+
+func (opt remoteStoreOption) apply(parser *configParser) {
+	src := newRemoteSource(opt.store, opt.options)
+	entry := sourceEntry{
+		source:   src,
+		priority: PriorityRemote,
+	}
+	if opt.options.Watch {
+		entry.watch = true
+		entry.onChange = opt.options.OnChange
+		// The source applies the default options.
+		entry.watchCtx = src.options.Context
+	}
+	parser.sources = append(parser.sources, entry)
+}
+
+// WithRemoteStore allows fetching configuration from a remote key/value store,
+// such as HTTPStore. The values of the store take precedence over the default
+// values, and are overwritten by the configuration file, environment
+// variables and flags, similarly to viper's remote key/value stores.
+//
+// If the store cannot be reached, the parser falls back to the snapshot
+// cached at RemoteOptions.CacheFile, and returns an error if there is none.
+//
+// This option is not set by default.
+func WithRemoteStore(store RemoteStore, options RemoteOptions) remoteStoreOption {
+	return remoteStoreOption{
+		store:   store,
+		options: options,
+	}
+}
@@ -0,0 +1,19 @@
+package configer
+
+type configSectionOption string
+
+func (opt configSectionOption) apply(parser *configParser) {
+	parser.section = string(opt)
+}
+
+// WithConfigSection allows unmarshaling only the values under a config key to
+// the config struct. This is useful when different parts of a project, such
+// as subcommands, read their own section of the same configuration.
+//
+// e.g. with the section set to "serve", the value of the config option with
+// key "serve.port" is unmarshaled to the Port field of the struct.
+//
+// By default, the whole configuration is unmarshaled.
+func WithConfigSection(key string) configSectionOption {
+	return configSectionOption(key)
+}
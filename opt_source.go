@@ -0,0 +1,57 @@
+package configer
+
+import "context"
+
+type sourceOption sourceEntry
+
+func (opt sourceOption) apply(parser *configParser) {
+	entry := sourceEntry(opt)
+	_, entry.watch = opt.source.(Watcher)
+	parser.sources = append(parser.sources, entry)
+}
+
+// WithSource allows loading configuration from a custom source, such as an
+// in-house store, a database table or the output of a command. The values of
+// the source take precedence over the values of sources with a lower
+// priority. PriorityRemote and PriorityFile can be used to position the
+// source relative to the built-in sources, e.g. PriorityFile+1 overwrites the
+// configuration file.
+//
+// Regardless of their priority, sources are always overwritten by
+// environment variables and flags, and always take precedence over the
+// default values.
+//
+// If the source implements Watcher, it is watched for changes once the config
+// is created, provided a handler was set via WithSourceChangeHandler. A
+// running Reloader also watches the source, and reloads the configuration
+// every time it changes.
+//
+// This option is not set by default.
+func WithSource(src Source, priority int) sourceOption {
+	return sourceOption{
+		source:   src,
+		priority: priority,
+	}
+}
+
+type sourceChangeHandlerOption struct {
+	ctx      context.Context
+	onChange func(source string, values map[string]any)
+}
+
+func (opt sourceChangeHandlerOption) apply(parser *configParser) {
+	parser.watchCtx = opt.ctx
+	parser.onSourceChange = opt.onChange
+}
+
+// WithSourceChangeHandler enables watching the sources added via WithSource
+// which implement Watcher. The handler is called with the name and new values
+// of the source every time they change, until ctx is done.
+//
+// By default, sources are not watched.
+func WithSourceChangeHandler(ctx context.Context, onChange func(source string, values map[string]any)) sourceChangeHandlerOption {
+	return sourceChangeHandlerOption{
+		ctx:      ctx,
+		onChange: onChange,
+	}
+}
@@ -0,0 +1,25 @@
+package configer
+
+type standardPathsOption string
+
+func (opt standardPathsOption) apply(parser *configParser) {
+	parser.configPaths = append(parser.configPaths, standardConfigPaths(string(opt))...)
+}
+
+// WithStandardConfigPaths adds the standard locations of the config files of
+// the app to the paths where the parser searches for a configuration file, in
+// this order:
+//
+//   - $XDG_CONFIG_HOME/<app>, if XDG_CONFIG_HOME is set
+//   - ~/.config/<app>
+//   - /etc/<app>
+//   - the directory of the running executable
+//
+// If app is empty, the name of the executable from os.Args[0] is used. The
+// paths are searched after the ones added via WithConfigPath before this
+// option, and before the ones added after it.
+//
+// By default, these paths are not searched.
+func WithStandardConfigPaths(app string) standardPathsOption {
+	return standardPathsOption(app)
+}
@@ -4,12 +4,15 @@ type configTypeOption string
 
 func (opt configTypeOption) apply(parser *configParser) {
 	parser.changeConfigExtension(string(opt))
-	parser.viper.SetConfigType(string(opt))
 }
 
 // WithConfigType allows specifying the type of the configuration file the
-// parser looks for. Currently, the parser supports searching for a single
-// file type.
+// parser looks for. Use WithConfigCandidates to search for multiple file
+// types.
+//
+// Config files read via WithConfigFile or --read-config and written via
+// --write-config have the type inferred from their extension. The type set
+// via this option is used for files without a known extension.
 //
 // By default, the parser searches for yaml configuration files.
 func WithConfigType(configType string) configTypeOption {
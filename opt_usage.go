@@ -0,0 +1,18 @@
+package configer
+
+type usageOption bool
+
+func (opt usageOption) apply(parser *configParser) {
+	parser.usage = bool(opt)
+}
+
+// WithUsage replaces the usage message displayed via the --help flag with one
+// that also lists, for every config option, the environment variable and
+// config key it can be set with, and the locations where the parser looks for
+// the configuration file. Options are grouped by the top-level part of their
+// config key.
+//
+// By default, the usage message of the flag set is displayed.
+func WithUsage() usageOption {
+	return usageOption(true)
+}
@@ -14,8 +14,10 @@ func (opt writeFlagOption) apply(parser *configParser) {
 // WithConfigType, or the default values if those were not set. Otherwise,
 // if the location is a file, it will try to read the config from that file.
 //
-// If there already is a configuration file at the specified location, this
-// option will overwrite that file.
+// If there already is a configuration file at the specified location, the
+// parser returns an error, unless the --force flag is also supplied. The
+// file is written to a temporary file first and then moved into place, such
+// that it is never partially written.
 //
 // By default, this flag will not be defined.
 func WithWriteFlag() writeFlagOption {
@@ -0,0 +1,18 @@
+package configer
+
+type writeModeOption WriteMode
+
+func (opt writeModeOption) apply(parser *configParser) {
+	parser.writeMode = WriteMode(opt)
+}
+
+// WithWriteMode allows specifying which values are written via
+// --write-config: all values (WriteAll), only the ones which differ from the
+// default values (WriteOverlay), or only the values of the flags supplied on
+// the command line (WriteFlags). The mode may also be selected via the
+// --write-mode flag, defined together with the write flag.
+//
+// By default, all values are written.
+func WithWriteMode(mode WriteMode) writeModeOption {
+	return writeModeOption(mode)
+}
@@ -0,0 +1,40 @@
+package configer
+
+import "os"
+
+type writeModesOption struct {
+	fileMode os.FileMode
+	dirMode  os.FileMode
+}
+
+func (opt writeModesOption) apply(parser *configParser) {
+	parser.fileMode = opt.fileMode
+	parser.dirMode = opt.dirMode
+}
+
+// WithWriteModes allows specifying the permissions of the config files and
+// directories created via --write-config.
+//
+// By default, config files are created with the 0644 permissions, or 0600 if
+// any option is secret or any value was encrypted, and directories with the
+// 0755 permissions.
+func WithWriteModes(fileMode, dirMode os.FileMode) writeModesOption {
+	return writeModesOption{
+		fileMode: fileMode,
+		dirMode:  dirMode,
+	}
+}
+
+type writeBackupOption bool
+
+func (opt writeBackupOption) apply(parser *configParser) {
+	parser.writeBackup = bool(opt)
+}
+
+// WithWriteBackup keeps a copy of the config file overwritten via
+// --write-config, with the ".bak" suffix appended to its name.
+//
+// By default, no copy is kept.
+func WithWriteBackup() writeBackupOption {
+	return writeBackupOption(true)
+}
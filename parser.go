@@ -1,9 +1,12 @@
 package configer
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/spf13/pflag"
@@ -12,10 +15,104 @@ import (
 
 type configParser struct {
 	viper        *viper.Viper
+	flags        *pflag.FlagSet
 	readFlag     bool
 	writeFlag    bool
 	configName   string
+	configPaths  []string
+	configFile   string
+	section      string
 	suppressLogs bool
+	debugLogs    bool
+	usage        bool
+	diffFlag     bool
+
+	// Names of the config files searched for in each config path, if
+	// different from configName.
+	configCandidateNames []string
+
+	// Directories containing config fragments merged on top of the config
+	// file.
+	dropInDirs []string
+
+	// Whether to resolve include directives inside config files.
+	includes bool
+
+	// Whether to publish the fingerprint of the configuration, the expvar
+	// it is published as and whether secret values are excluded.
+	fingerprint               bool
+	fingerprintVar            string
+	fingerprintExcludeSecrets bool
+
+	// Whether to define the config type flag, the type supplied via the flag
+	// and whether to infer the type of files without an extension from their
+	// content.
+	configTypeFlag bool
+	formatOverride string
+	sniffFormat    bool
+
+	// Permissions of the files and directories created via the write flag,
+	// and whether to back up an overwritten file.
+	fileMode    os.FileMode
+	dirMode     os.FileMode
+	writeBackup bool
+	writeMode   WriteMode
+
+	// The default value of every config key, including the ones set by the
+	// default config file.
+	defaultValues map[string]any
+
+	// Used to compute the environment variable associated with a config
+	// key.
+	envPrefix      string
+	envKeyReplacer string
+
+	// Separator used when decoding lists and maps from strings.
+	listSeparator string
+
+	// File providing default values, usually embedded in the binary.
+	defaultFileFS   fs.FS
+	defaultFileName string
+
+	// Sources the configuration is loaded from, other than the defaults,
+	// configuration file, environment variables and flags.
+	sources []sourceEntry
+	// Called when the values of a watched source change.
+	onSourceChange func(source string, values map[string]any)
+	watchCtx       context.Context
+	// Notified every time a watched source changes.
+	sourceChanges chan struct{}
+
+	// Key used to decrypt encrypted configuration values, and the values
+	// that were decrypted, by config key.
+	decryptionKeyFile string
+	decryptionKeyEnv  string
+	encrypted         map[string]encryptedValue
+
+	// Current schema version of the configuration file, the migrations from
+	// each older version and the deprecated config keys.
+	configVersion int
+	migrations    map[int]Migration
+	aliases       []keyAlias
+
+	// The config keys bound to environment variables.
+	envKeys map[string]bool
+
+	// Relationships between config options.
+	groups []optionGroup
+
+	// The config keys of the options whose values are sensitive, and the
+	// keys set by the default config file.
+	secrets         map[string]bool
+	defaultFileKeys map[string]bool
+
+	// Configuration values supplied by each source, other than defaults,
+	// environment variables and flags.
+	layers []layer
+	// The sources of the values kept from the previous configuration when
+	// reloading, by config key. The kept values override the ones of the
+	// sources, so they must not be attributed to them.
+	keptSources map[string]string
 
 	// Update to slog once go 1.21 is out.
 	log *log.Logger
@@ -24,10 +121,19 @@ type configParser struct {
 // newParser initializes a project parser with some default options.
 func newParser() *configParser {
 	parser := &configParser{
-		viper:      viper.New(),
-		writeFlag:  false,
-		readFlag:   false,
-		configName: "config.yml",
+		viper:           viper.New(),
+		writeFlag:       false,
+		readFlag:        false,
+		configName:      "config.yml",
+		encrypted:       make(map[string]encryptedValue),
+		migrations:      make(map[int]Migration),
+		secrets:         make(map[string]bool),
+		defaultFileKeys: make(map[string]bool),
+		defaultValues:   make(map[string]any),
+		envKeys:         make(map[string]bool),
+		keptSources:     make(map[string]string),
+		sourceChanges:   make(chan struct{}, 1),
+		writeMode:       WriteAll,
 		// Based on flags, the logger may be updated.
 		log: log.New(os.Stderr, "", 0),
 	}
@@ -38,12 +144,12 @@ func newParser() *configParser {
 // setDefaultParserOptions sets the default parser options that are often good
 // enough for most projects.
 func (p *configParser) setDefaultParserOptions() {
-	// Looks for config.yml in the current directory by default.
-	p.viper.SetConfigName("config")
-	p.viper.SetConfigType("yml")
-
+	p.listSeparator = ","
+	p.envKeyReplacer = "_"
 	p.viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	p.viper.AutomaticEnv()
+	// AutomaticEnv is not used, since viper would look up the derived
+	// variable of every key before the explicit ones. The variables are
+	// bound per key instead, see bindEnvVars.
 }
 
 // applyOptions applies the parser options that were supplied to the parser.
@@ -56,7 +162,7 @@ func (p *configParser) applyOptions(options ...ParserOption) {
 // setDefaultValues sets the default values of the config, specified through
 // ConfigOptions. These values may be overwritten, in this order of precedence,
 // by flags, environment variables and configuration files. Any configuration
-// option must provide a default value.
+// option must provide a default value, unless it is required.
 func (p *configParser) setDefaultValues(opts []ConfigOption) error {
 	for _, opt := range opts {
 
@@ -64,7 +170,15 @@ func (p *configParser) setDefaultValues(opts []ConfigOption) error {
 		if opt.ConfigKey == "" {
 			continue
 		}
-		p.viper.SetDefault(opt.ConfigKey, opt.Value)
+		// The values of required options only determine the type of their
+		// flags.
+		if !opt.Required {
+			p.viper.SetDefault(opt.ConfigKey, opt.Value)
+			p.defaultValues[strings.ToLower(opt.ConfigKey)] = opt.Value
+		}
+		if opt.Secret {
+			p.secrets[strings.ToLower(opt.ConfigKey)] = true
+		}
 
 		// Bind to the defined flags. Flags may be left empty.
 		//
@@ -72,16 +186,113 @@ func (p *configParser) setDefaultValues(opts []ConfigOption) error {
 		// called BindPFlags to bind all flags at once, but that cannot be used
 		// since it uses each flag's full name as the config key
 		// (see function documentation).
-		if f := pflag.Lookup(opt.FlagName); f != nil {
+		if f := p.flags.Lookup(opt.FlagName); f != nil {
 			err := p.viper.BindPFlag(opt.ConfigKey, f)
 			if err != nil {
 				return fmt.Errorf("could not bind to flag %s: %w", opt.FlagName, err)
 			}
 		}
 	}
+
+	// Files written via --write-config must store the current schema
+	// version.
+	if p.configVersion != 0 {
+		p.viper.SetDefault(versionKey, p.configVersion)
+	}
+	return nil
+}
+
+// envName returns the environment variable associated with a config key,
+// the same way viper computes it.
+func (p *configParser) envName(key string) string {
+	name := strings.ReplaceAll(key, ".", p.envKeyReplacer)
+	if p.envPrefix != "" {
+		name = p.envPrefix + "_" + name
+	}
+	return strings.ToUpper(name)
+}
+
+// unmarshal decodes the configuration to the provided struct. If a config
+// section was set via WithConfigSection, only the values under the section
+// key are decoded.
+func (p *configParser) unmarshal(configStruct interface{}) error {
+	if p.section == "" {
+		return p.viper.Unmarshal(configStruct, decoderOptions(p.listSeparator))
+	}
+
+	// The subtree is taken from all settings, since viper.Sub doesn't merge
+	// the values of the different sources.
+	subtree, _ := getNestedValue(p.viper.AllSettings(), p.section)
+	values, _ := toStringMap(subtree)
+
+	v := viper.New()
+	if err := v.MergeConfigMap(values); err != nil {
+		return fmt.Errorf("could not read config section %s: %w", p.section, err)
+	}
+	return v.Unmarshal(configStruct, decoderOptions(p.listSeparator))
+}
+
+// setDefaultFileValues sets the values read from the file provided via
+// WithDefaultConfigFile as defaults. Since these are set after the values of
+// the config options, they take precedence over those.
+func (p *configParser) setDefaultFileValues() error {
+	if p.defaultFileFS == nil {
+		return nil
+	}
+
+	f, err := p.defaultFileFS.Open(p.defaultFileName)
+	if err != nil {
+		return fmt.Errorf("could not open default config file %s: %w", p.defaultFileName, err)
+	}
+	defer f.Close()
+
+	configType := strings.TrimPrefix(path.Ext(p.defaultFileName), ".")
+	if configType == "" {
+		configType = p.configExtension()
+	}
+
+	values, err := readConfigValues(f, configType)
+	if err != nil {
+		return fmt.Errorf("could not parse default config file %s: %w", p.defaultFileName, err)
+	}
+
+	// Defaults are set per key, otherwise setting a nested map would discard
+	// the defaults of the config options sharing the same parent key.
+	for key, value := range flattenValues(values) {
+		p.viper.SetDefault(key, value)
+		p.defaultFileKeys[key] = true
+		p.defaultValues[key] = value
+	}
 	return nil
 }
 
+// debugf logs a debug message, if debug logs are enabled.
+func (p *configParser) debugf(format string, v ...any) {
+	if p.debugLogs {
+		p.log.Printf("[configer debug] "+format+"\n", v...)
+	}
+}
+
+// configBaseName returns the name of the config file the parser looks for,
+// without extension.
+func (p *configParser) configBaseName() string {
+	parts := strings.Split(p.configName, ".")
+	if len(parts) != 2 {
+		panic("internal parser error: invalid config name")
+	}
+	return parts[0]
+}
+
+// configExtension returns the extension of the config file the parser looks
+// for.
+func (p *configParser) configExtension() string {
+	parts := strings.Split(p.configName, ".")
+	if len(parts) != 2 {
+		panic("internal parser error: invalid config name")
+	}
+	return parts[1]
+}
+
 // changeConfigName is a helper method that changes the internal config file
 // name stored by the parser.
 func (p *configParser) changeConfigName(name string) {
@@ -1,9 +1,14 @@
 package configer
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -55,6 +60,15 @@ func initFile(t *testing.T, name string, content []byte) (*os.File, string) {
 	return f, dir
 }
 
+// resetFlags clears the command-line flags before and after the test, to allow
+// initializing multiple configs.
+func resetFlags(t *testing.T) {
+	pflag.CommandLine = pflag.NewFlagSet("", pflag.PanicOnError)
+	t.Cleanup(func() {
+		pflag.CommandLine = pflag.NewFlagSet("", pflag.PanicOnError)
+	})
+}
+
 func checkExample1(t *testing.T, expected, actual Example1) {
 	if expected.Numberr != actual.Numberr {
 		t.Fatalf("invalid number: want %d, got %d", expected.Numberr, actual.Numberr)
@@ -122,3 +136,850 @@ func TestReadYamlDefaultValues(t *testing.T) {
 		Durationn: 2 * time.Second,
 	})
 }
+
+func TestReadDefaultConfigFile(t *testing.T) {
+	f, dir := initFile(t, "test.yml", []byte("numberr: 13\n"))
+	defer f.Close()
+
+	defaults := fstest.MapFS{
+		"config.default.yml": {Data: []byte("numberr: 7\nstringg: embedded\n")},
+	}
+
+	resetFlags(t)
+
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithConfigName("test"),
+		WithConfigType("yml"),
+		WithConfigPath(dir),
+		WithDefaultConfigFile(defaults, "config.default.yml"),
+		WithSupressLogs())
+
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+
+	checkExample1(t, ex, Example1{
+		Numberr:   13,
+		Stringg:   "embedded",
+		Booll:     false,
+		Durationn: 2 * time.Second,
+	})
+}
+
+func TestReadEnvVarAliases(t *testing.T) {
+	resetFlags(t)
+	t.Setenv("LEGACY_NUMBER", "21")
+
+	opts := getyamlopts()
+	opts[0].EnvVars = []string{"APP_NUMBER", "LEGACY_NUMBER"}
+	opts[0].DeprecatedEnvVars = []string{"LEGACY_NUMBER"}
+
+	ex := Example1{}
+	err := NewConfig(&ex, opts,
+		WithConfigName("garbage"),
+		WithSupressLogs())
+
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+	if ex.Numberr != 21 {
+		t.Fatalf("invalid number: want %d, got %d", 21, ex.Numberr)
+	}
+
+	resetFlags(t)
+	t.Setenv("APP_NUMBER", "22")
+
+	err = NewConfig(&ex, opts,
+		WithConfigName("garbage"),
+		WithSupressLogs())
+
+	if err == nil {
+		t.Fatalf("expected error for conflicting environment variables")
+	}
+}
+
+func TestReadExplicitEnvVarsOnly(t *testing.T) {
+	f, dir := initFile(t, "test.yml", []byte("extra:\n  value: file\n"))
+	f.Close()
+
+	opts := getyamlopts()
+	opts[0].EnvVars = []string{"PORT"}
+	opts[0].Required = true
+
+	load := func() (*Snapshot, error) {
+		resetFlags(t)
+		withArgs(t)
+		return NewSnapshot(opts,
+			WithConfigName("test"),
+			WithConfigPath(dir),
+			WithEnvPrefix("APP"),
+			WithSupressLogs())
+	}
+
+	// The derived variable is not used, since explicit variables were
+	// provided.
+	t.Setenv("APP_NUMBERR", "2")
+	if _, err := load(); err == nil || !strings.Contains(err.Error(), "missing required option numberr: set it via flag --0-numberr, or environment variable PORT") {
+		t.Fatalf("expected missing required option error, got %v", err)
+	}
+
+	t.Setenv("PORT", "1")
+	t.Setenv("APP_EXTRA_VALUE", "env")
+	snapshot, err := load()
+	if err != nil {
+		t.Fatalf("could not create snapshot: %s", err.Error())
+	}
+	if n := snapshot.GetInt("numberr"); n != 1 {
+		t.Fatalf("invalid number: want %d, got %d", 1, n)
+	}
+	if source := snapshot.Source("numberr"); source != "environment variable PORT" {
+		t.Fatalf("invalid source of numberr: %s", source)
+	}
+
+	// Keys that are not associated with options use the derived variable.
+	if v := snapshot.GetString("extra.value"); v != "env" {
+		t.Fatalf("invalid extra value: want %s, got %s", "env", v)
+	}
+}
+
+type Server struct {
+	Host string
+	Port int
+}
+
+type Example2 struct {
+	Hosts   []string
+	Labels  map[string]string
+	Ports   []int
+	Servers []Server
+}
+
+func TestReadEnvCollections(t *testing.T) {
+	resetFlags(t)
+	t.Setenv("DEMO_HOSTS", "a;b")
+	t.Setenv("DEMO_LABELS", "k1=v1;k2=v2")
+	t.Setenv("DEMO_PORTS", "[80, 443]")
+	t.Setenv("DEMO_SERVERS_0_HOST", "first")
+	t.Setenv("DEMO_SERVERS_0_PORT", "8080")
+	t.Setenv("DEMO_SERVERS_1_HOST", "second")
+
+	ex := Example2{}
+	err := NewConfig(&ex, []ConfigOption{
+		{Value: []string{}, ConfigKey: "hosts"},
+		{Value: map[string]string{}, ConfigKey: "labels"},
+		{Value: []int{}, ConfigKey: "ports"},
+		{Value: []Server{}, ConfigKey: "servers"},
+	},
+		WithConfigName("garbage"),
+		WithEnvPrefix("DEMO"),
+		WithListSeparator(";"),
+		WithSupressLogs())
+
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+
+	if len(ex.Hosts) != 2 || ex.Hosts[1] != "b" {
+		t.Fatalf("invalid hosts: %v", ex.Hosts)
+	}
+	if len(ex.Labels) != 2 || ex.Labels["k2"] != "v2" {
+		t.Fatalf("invalid labels: %v", ex.Labels)
+	}
+	if len(ex.Ports) != 2 || ex.Ports[1] != 443 {
+		t.Fatalf("invalid ports: %v", ex.Ports)
+	}
+	if len(ex.Servers) != 2 || ex.Servers[0].Port != 8080 || ex.Servers[1].Host != "second" {
+		t.Fatalf("invalid servers: %+v", ex.Servers)
+	}
+}
+
+func TestReadMissingRequiredOptions(t *testing.T) {
+	resetFlags(t)
+
+	opts := getyamlopts()
+	opts[0].Required = true
+	opts[1].Required = true
+
+	ex := Example1{}
+	err := NewConfig(&ex, opts,
+		WithConfigName("garbage"),
+		WithSupressLogs())
+
+	if err == nil {
+		t.Fatalf("expected error for missing required options")
+	}
+	for _, key := range []string{"numberr", "stringg", "--0-numberr", "STRINGG"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Fatalf("error doesn't mention %s: %s", key, err.Error())
+		}
+	}
+}
+
+func TestWriteRequiredOptions(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.yml")
+	opts := func() []ConfigOption {
+		opts := getyamlopts()
+		opts[1].Required = true
+		return opts
+	}
+
+	// The value of a missing required option is only a placeholder, so the
+	// config must not be written.
+	resetFlags(t)
+	withArgs(t, "--write-config", out)
+	err := NewConfig(&Example1{}, opts(),
+		WithConfigName("garbage"),
+		WithWriteFlag(),
+		WithSupressLogs())
+	if err == nil || !strings.Contains(err.Error(), "missing required option stringg") {
+		t.Fatalf("expected missing required option error, got %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("config missing required options was written")
+	}
+
+	resetFlags(t)
+	withArgs(t, "--write-config", out, "--0-stringg", "set")
+	err = NewConfig(&Example1{}, opts(),
+		WithConfigName("garbage"),
+		WithWriteFlag(),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not write config: %s", err.Error())
+	}
+
+	resetFlags(t)
+	withArgs(t)
+	ex := Example1{}
+	err = NewConfig(&ex, opts(),
+		WithConfigFile(out),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not read written config: %s", err.Error())
+	}
+	if ex.Stringg != "set" {
+		t.Fatalf("invalid string: want %s, got %s", "set", ex.Stringg)
+	}
+}
+
+func TestReadOptionGroups(t *testing.T) {
+	f, dir := initFile(t, "test.yml", []byte("numberr: 13\nbooll: true\ndurationn: 1s\n"))
+	defer f.Close()
+
+	resetFlags(t)
+
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithConfigName("test"),
+		WithConfigType("yml"),
+		WithConfigPath(dir),
+		WithRequiredTogether("numberr", "stringg"),
+		WithMutuallyExclusive("booll", "durationn"),
+		WithExactlyOneOf([]string{"numberr"}, []string{"booll", "stringg"}),
+		WithSupressLogs())
+
+	if err == nil {
+		t.Fatalf("expected error for invalid option groups")
+	}
+	for _, msg := range []string{"stringg is not set", "booll, durationn are set"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("error doesn't contain %q: %s", msg, err.Error())
+		}
+	}
+}
+
+func TestReadSnapshot(t *testing.T) {
+	f, dir := initFile(t, "test.yml", []byte("cache:\n  size: 10\n  ttl: 1m\n"))
+	defer f.Close()
+
+	resetFlags(t)
+
+	snapshot, err := NewSnapshot([]ConfigOption{
+		{FlagName: "cache-size", Value: 5, ConfigKey: "cache.size"},
+		{Value: "redis", ConfigKey: "cache.driver"},
+	},
+		WithConfigName("test"),
+		WithConfigType("yml"),
+		WithConfigPath(dir),
+		WithSupressLogs())
+
+	if err != nil {
+		t.Fatalf("call to new snapshot failed: %s", err.Error())
+	}
+
+	if d := snapshot.GetDuration("cache.ttl"); d != time.Minute {
+		t.Fatalf("invalid duration: want %v, got %v", time.Minute, d)
+	}
+	if !snapshot.IsSet("cache.driver") || snapshot.IsSet("cache.missing") {
+		t.Fatalf("invalid keys: %v", snapshot.AllKeys())
+	}
+
+	var cache struct {
+		Size   int
+		Driver string
+	}
+	if err := snapshot.Sub("cache").Unmarshal(&cache); err != nil {
+		t.Fatalf("could not unmarshal section: %s", err.Error())
+	}
+	if cache.Size != 10 || cache.Driver != "redis" {
+		t.Fatalf("invalid section: %+v", cache)
+	}
+}
+
+func TestDiffCandidateConfig(t *testing.T) {
+	f, dir := initFile(t, "test.yml", []byte("numberr: 13\nstringg: current\n"))
+	defer f.Close()
+
+	candidate := filepath.Join(t.TempDir(), "candidate.yml")
+	if err := os.WriteFile(candidate, []byte("stringg: candidate\nbooll: true\n"), 0600); err != nil {
+		t.Fatalf("could not write candidate: %s", err.Error())
+	}
+
+	resetFlags(t)
+
+	opts := getyamlopts()
+	opts[1].Secret = true
+
+	loader, err := RegisterFlags(pflag.CommandLine, opts,
+		WithConfigName("test"),
+		WithConfigType("yml"),
+		WithConfigPath(dir),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not register flags: %s", err.Error())
+	}
+	pflag.Parse()
+
+	current, err := loader.LoadSnapshot()
+	if err != nil {
+		t.Fatalf("could not load snapshot: %s", err.Error())
+	}
+	next, err := loader.CandidateSnapshot(candidate)
+	if err != nil {
+		t.Fatalf("could not load candidate: %s", err.Error())
+	}
+
+	changes := Diff(current, next)
+	if len(changes) != 3 {
+		t.Fatalf("invalid number of changes: want 3, got %+v", changes)
+	}
+	if changes[0].Key != "booll" || changes[0].Kind != Changed || changes[0].NewSource != candidate {
+		t.Fatalf("invalid change: %+v", changes[0])
+	}
+	if changes[1].Key != "numberr" || changes[1].New != 4 || changes[1].NewSource != sourceDefault {
+		t.Fatalf("invalid change: %+v", changes[1])
+	}
+	if changes[2].Key != "stringg" || changes[2].Old != redacted || changes[2].New != redacted {
+		t.Fatalf("secret was not redacted: %+v", changes[2])
+	}
+}
+
+// withArgs replaces the command-line arguments parsed by NewConfig for the
+// duration of the test.
+func withArgs(t *testing.T, args ...string) {
+	old := os.Args
+	os.Args = append([]string{old[0]}, args...)
+	t.Cleanup(func() {
+		os.Args = old
+	})
+}
+
+func TestWriteConfig(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "nested", "out.yml")
+
+	opts := getyamlopts()
+	opts[1].Secret = true
+
+	write := func(args ...string) error {
+		resetFlags(t)
+		withArgs(t, args...)
+		ex := Example1{}
+		return NewConfig(&ex, opts,
+			WithConfigName("garbage"),
+			WithWriteFlag(),
+			WithWriteBackup(),
+			WithSupressLogs())
+	}
+
+	if err := write("--write-config", out); err != nil {
+		t.Fatalf("could not write config: %s", err.Error())
+	}
+	stat, err := os.Stat(out)
+	if err != nil {
+		t.Fatalf("config was not written: %s", err.Error())
+	}
+	if stat.Mode().Perm() != 0o600 {
+		t.Fatalf("invalid permissions: want %v, got %v", os.FileMode(0o600), stat.Mode().Perm())
+	}
+
+	if err := write("--write-config", out); err == nil {
+		t.Fatalf("expected error when overwriting without --force")
+	}
+	if err := write("--write-config", out, "--force"); err != nil {
+		t.Fatalf("could not overwrite config: %s", err.Error())
+	}
+	if _, err := os.Stat(out + ".bak"); err != nil {
+		t.Fatalf("previous config was not backed up: %s", err.Error())
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(out))
+	if len(entries) != 2 {
+		t.Fatalf("temporary files were left behind: %v", entries)
+	}
+}
+
+func TestSameValue(t *testing.T) {
+	if !sameValue(time.Minute, "1m", time.Second, ",") || !sameValue(8080, "8080", 0, ",") {
+		t.Fatalf("equal values of different types are different")
+	}
+	if !sameValue([]string{"a", "b"}, "a,b", []string{}, ",") {
+		t.Fatalf("equal lists are different")
+	}
+	if sameValue(time.Minute, "61s", time.Second, ",") {
+		t.Fatalf("different durations are equal")
+	}
+	// The printed form of a value is not the value.
+	if sameValue([]string{"a", "b"}, "[a b]", []string{}, ",") || sameValue("1", 1, nil, ",") {
+		t.Fatalf("different values with the same printed form are equal")
+	}
+}
+
+func TestWriteFileExclusive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+
+	if err := writeFileExclusive(path, []byte("numberr: 1\n"), 0o600); err != nil {
+		t.Fatalf("could not write file: %s", err.Error())
+	}
+	// The file may be created after the parser checked for it, so it is
+	// never replaced.
+	err := writeFileExclusive(path, []byte("numberr: 2\n"), 0o600)
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected error when the file exists, got %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read file: %s", err.Error())
+	}
+	if string(content) != "numberr: 1\n" {
+		t.Fatalf("existing file was replaced: %s", content)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("could not get stats of file: %s", err.Error())
+	}
+	if stat.Mode().Perm() != 0o600 {
+		t.Fatalf("invalid permissions: want %v, got %v", os.FileMode(0o600), stat.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("temporary files were left behind: %v", entries)
+	}
+}
+
+func TestWriteConfigModes(t *testing.T) {
+	dir := t.TempDir()
+	f, fdir := initFile(t, "test.yml", []byte("stringg: hello\n"))
+	f.Close()
+
+	write := func(out string, args ...string) map[string]any {
+		resetFlags(t)
+		withArgs(t, append([]string{"--write-config", out}, args...)...)
+		ex := Example1{}
+		err := NewConfig(&ex, getyamlopts(),
+			WithConfigName("test"),
+			WithConfigPath(fdir),
+			WithWriteFlag(),
+			WithWriteMode(WriteOverlay),
+			WithSupressLogs())
+		if err != nil {
+			t.Fatalf("could not write config: %s", err.Error())
+		}
+		content, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("config was not written: %s", err.Error())
+		}
+		values, err := readConfigValues(strings.NewReader(string(content)), "yml")
+		if err != nil {
+			t.Fatalf("could not read written config: %s", err.Error())
+		}
+		return values
+	}
+
+	overlay := write(filepath.Join(dir, "overlay.yml"), "--0-numberr", "7", "--0-durationn", "2s")
+	if len(overlay) != 2 || overlay["numberr"] != 7 || overlay["stringg"] != "hello" {
+		t.Fatalf("invalid overlay config: %v", overlay)
+	}
+
+	flags := write(filepath.Join(dir, "flags.yml"), "--0-numberr", "7", "--write-mode", "flags")
+	if len(flags) != 1 || flags["numberr"] != 7 {
+		t.Fatalf("invalid flags config: %v", flags)
+	}
+}
+
+func TestReadConfigFormats(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "prod.json")
+	if err := os.WriteFile(jsonFile, []byte(`{"numberr": 13, "stringg": "hello"}`), 0o600); err != nil {
+		t.Fatalf("could not write test file: %s", err.Error())
+	}
+	extensionless := filepath.Join(dir, "prod")
+	if err := os.WriteFile(extensionless, []byte("numberr = 14\nstringg = \"toml\"\n"), 0o600); err != nil {
+		t.Fatalf("could not write test file: %s", err.Error())
+	}
+
+	read := func(args ...string) Example1 {
+		resetFlags(t)
+		withArgs(t, args...)
+		ex := Example1{}
+		err := NewConfig(&ex, getyamlopts(),
+			WithReadFlag(),
+			WithWriteFlag(),
+			WithConfigTypeFlag(),
+			WithFormatSniffing(),
+			WithSupressLogs())
+		if err != nil {
+			t.Fatalf("could not read config: %s", err.Error())
+		}
+		return ex
+	}
+
+	if ex := read("--read-config", jsonFile); ex.Numberr != 13 || ex.Stringg != "hello" {
+		t.Fatalf("invalid config read from json file: %+v", ex)
+	}
+	if ex := read("--read-config", extensionless); ex.Numberr != 14 || ex.Stringg != "toml" {
+		t.Fatalf("invalid config read from extensionless file: %+v", ex)
+	}
+
+	out := filepath.Join(dir, "out.toml")
+	read("--read-config", jsonFile, "--write-config", out)
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("config was not written: %s", err.Error())
+	}
+	if sniffFormat(content) != "toml" {
+		t.Fatalf("config was not written as toml:\n%s", content)
+	}
+
+	copied := filepath.Join(dir, "prod.conf")
+	if err := os.WriteFile(copied, content, 0o600); err != nil {
+		t.Fatalf("could not write test file: %s", err.Error())
+	}
+	if ex := read("--read-config", copied, "--config-type", "toml"); ex.Numberr != 13 {
+		t.Fatalf("invalid config read with explicit type: %+v", ex)
+	}
+}
+
+func TestConfigTypeFlagOnlyForFlags(t *testing.T) {
+	f, dir := initFile(t, "test.yml", []byte("numberr: 13\n"))
+	defer f.Close()
+	out := filepath.Join(t.TempDir(), "out.conf")
+
+	// The searched file is read as yaml, while the file written via flag
+	// has the type supplied via flag.
+	resetFlags(t)
+	withArgs(t, "--config-type", "json", "--write-config", out)
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithConfigName("test"),
+		WithConfigType("yml"),
+		WithConfigPath(dir),
+		WithWriteFlag(),
+		WithConfigTypeFlag(),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+	if ex.Numberr != 13 {
+		t.Fatalf("invalid number: want %d, got %d", 13, ex.Numberr)
+	}
+
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("config was not written: %s", err.Error())
+	}
+	if sniffFormat(content) != "json" {
+		t.Fatalf("config was not written as json:\n%s", content)
+	}
+}
+
+func TestReadConfigCandidates(t *testing.T) {
+	empty, first, second := t.TempDir(), t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(first, "app.toml"), []byte("numberr = 13\n"), 0o600); err != nil {
+		t.Fatalf("could not write test file: %s", err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(second, "config.yml"), []byte("numberr: 14\n"), 0o600); err != nil {
+		t.Fatalf("could not write test file: %s", err.Error())
+	}
+
+	read := func(paths ...string) (Example1, error) {
+		resetFlags(t)
+		withArgs(t)
+		opts := []ParserOption{
+			WithConfigCandidates("config.yaml", "config.yml", "app.toml"),
+			WithSupressLogs(),
+		}
+		for _, path := range paths {
+			opts = append(opts, WithConfigPath(path))
+		}
+		ex := Example1{}
+		err := NewConfig(&ex, getyamlopts(), opts...)
+		return ex, err
+	}
+
+	ex, err := read(empty, first, second)
+	if err != nil {
+		t.Fatalf("could not read config: %s", err.Error())
+	}
+	if ex.Numberr != 13 {
+		t.Fatalf("invalid number: want %d, got %d", 13, ex.Numberr)
+	}
+
+	if err := os.WriteFile(filepath.Join(second, "config.yaml"), []byte("numberr: 15\n"), 0o600); err != nil {
+		t.Fatalf("could not write test file: %s", err.Error())
+	}
+	if _, err := read(second, first); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("expected ambiguity error, got %v", err)
+	}
+}
+
+func TestReadStandardConfigPaths(t *testing.T) {
+	xdg, home := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("HOME", home)
+
+	userDir := filepath.Join(home, ".config", "demo")
+	if err := os.MkdirAll(userDir, 0o755); err != nil {
+		t.Fatalf("could not create config directory: %s", err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(userDir, "config.yml"), []byte("numberr: 13\n"), 0o600); err != nil {
+		t.Fatalf("could not write test file: %s", err.Error())
+	}
+
+	paths := standardConfigPaths("demo")
+	if len(paths) < 3 || paths[0] != filepath.Join(xdg, "demo") || paths[1] != userDir || paths[2] != "/etc/demo" {
+		t.Fatalf("invalid standard paths: %v", paths)
+	}
+
+	resetFlags(t)
+	withArgs(t)
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithStandardConfigPaths("demo"),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not read config: %s", err.Error())
+	}
+	if ex.Numberr != 13 {
+		t.Fatalf("invalid number: want %d, got %d", 13, ex.Numberr)
+	}
+
+	t.Setenv("DEMO_DIR", "demo")
+	if path := expandPath("~/.config/$DEMO_DIR/"); path != userDir+"/" {
+		t.Fatalf("invalid expanded path: want %s, got %s", userDir+"/", path)
+	}
+}
+
+func TestReadDropInDir(t *testing.T) {
+	f, dir := initFile(t, "test.yml", []byte("numberr: 13\nstringg: hello\n"))
+	f.Close()
+
+	confd := filepath.Join(dir, "conf.d")
+	fragments := map[string]string{
+		"10-number.yml":  "numberr: 14\n",
+		"20-number.json": `{"numberr": 15}`,
+		"30-string.toml": "stringg = \"fragment\"\n",
+		"README":         "not a fragment",
+	}
+	if err := os.MkdirAll(confd, 0o755); err != nil {
+		t.Fatalf("could not create drop-in directory: %s", err.Error())
+	}
+	for name, content := range fragments {
+		if err := os.WriteFile(filepath.Join(confd, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("could not write test file: %s", err.Error())
+		}
+	}
+
+	load := func() (*Snapshot, error) {
+		resetFlags(t)
+		withArgs(t)
+		return NewSnapshot(getyamlopts(),
+			WithConfigName("test"),
+			WithConfigPath(dir),
+			WithDropInDir(confd),
+			WithSupressLogs())
+	}
+
+	snapshot, err := load()
+	if err != nil {
+		t.Fatalf("could not read config: %s", err.Error())
+	}
+	if n := snapshot.GetInt("numberr"); n != 15 {
+		t.Fatalf("invalid number: want %d, got %d", 15, n)
+	}
+	if s := snapshot.GetString("stringg"); s != "fragment" {
+		t.Fatalf("invalid string: want %s, got %s", "fragment", s)
+	}
+	if source := snapshot.Source("numberr"); source != filepath.Join(confd, "20-number.json") {
+		t.Fatalf("invalid source of numberr: %s", source)
+	}
+
+	broken := filepath.Join(confd, "40-broken.yml")
+	if err := os.WriteFile(broken, []byte("numberr: [\n"), 0o600); err != nil {
+		t.Fatalf("could not write test file: %s", err.Error())
+	}
+	if _, err := load(); err == nil || !strings.Contains(err.Error(), broken) {
+		t.Fatalf("expected error naming %s, got %v", broken, err)
+	}
+}
+
+func TestReadIncludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yml":       "include: [database.yml, features/*.yml, features/b.json]\nnumberr: 13\nserver:\n  tls:\n    $include: ./tls/tls.yml\n    cert: main.pem\n",
+		"database.yml":     "numberr: 1\ndatabase:\n  host: localhost\n",
+		"features/a.yml":   "features:\n  a: true\n",
+		"features/b.json":  `{"features": {"b": true}}`,
+		"tls/tls.yml":      "cert: tls.pem\nkey: tls.key\n",
+		"cycle/a.yml":      "include: b.yml\n",
+		"cycle/b.yml":      "nested:\n  $include: ../cycle/a.yml\n",
+		"cycle/config.yml": "include: a.yml\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("could not create directory: %s", err.Error())
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("could not write test file: %s", err.Error())
+		}
+	}
+
+	load := func(path string) (*Snapshot, error) {
+		resetFlags(t)
+		withArgs(t)
+		return NewSnapshot(getyamlopts(),
+			WithConfigPath(path),
+			WithIncludes(),
+			WithSupressLogs())
+	}
+
+	snapshot, err := load(dir)
+	if err != nil {
+		t.Fatalf("could not read config: %s", err.Error())
+	}
+	expected := map[string]any{
+		"numberr":         13,
+		"database.host":   "localhost",
+		"features.a":      true,
+		"features.b":      true,
+		"server.tls.cert": "main.pem",
+		"server.tls.key":  "tls.key",
+	}
+	for key, value := range expected {
+		if actual := snapshot.Get(key); fmt.Sprint(actual) != fmt.Sprint(value) {
+			t.Fatalf("invalid value of %s: want %v, got %v", key, value, actual)
+		}
+	}
+	if snapshot.IsSet("include") || snapshot.IsSet("server.tls.$include") {
+		t.Fatalf("include directives were not removed")
+	}
+
+	_, err = load(filepath.Join(dir, "cycle"))
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("expected include cycle error, got %v", err)
+	}
+}
+
+func TestReadConfigStdin(t *testing.T) {
+	read := func(content string, args ...string) (Example1, error) {
+		stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+		if err != nil {
+			t.Fatalf("could not create stdin file: %s", err.Error())
+		}
+		defer stdin.Close()
+		if _, err := stdin.WriteString(content); err != nil {
+			t.Fatalf("could not write stdin file: %s", err.Error())
+		}
+		if _, err := stdin.Seek(0, 0); err != nil {
+			t.Fatalf("could not rewind stdin file: %s", err.Error())
+		}
+
+		old := os.Stdin
+		os.Stdin = stdin
+		defer func() { os.Stdin = old }()
+
+		resetFlags(t)
+		withArgs(t, append([]string{"--read-config", "-"}, args...)...)
+		ex := Example1{}
+		err = NewConfig(&ex, getyamlopts(),
+			WithReadFlag(),
+			WithConfigTypeFlag(),
+			WithSupressLogs())
+		return ex, err
+	}
+
+	ex, err := read("numberr: 13\n")
+	if err != nil {
+		t.Fatalf("could not read config from stdin: %s", err.Error())
+	}
+	if ex.Numberr != 13 {
+		t.Fatalf("invalid number: want %d, got %d", 13, ex.Numberr)
+	}
+
+	ex, err = read(`{"numberr": 14}`, "--config-type", "json")
+	if err != nil {
+		t.Fatalf("could not read json config from stdin: %s", err.Error())
+	}
+	if ex.Numberr != 14 {
+		t.Fatalf("invalid number: want %d, got %d", 14, ex.Numberr)
+	}
+}
+
+func TestReadConfigAnyExtension(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("numberr: 7\n"), 0o600); err != nil {
+		t.Fatalf("could not write test file: %s", err.Error())
+	}
+
+	read := func() Example1 {
+		resetFlags(t)
+		withArgs(t)
+		ex := Example1{}
+		err := NewConfig(&ex, getyamlopts(),
+			WithConfigName("config"),
+			WithConfigType("yml"),
+			WithConfigPath(dir),
+			WithSupressLogs())
+		if err != nil {
+			t.Fatalf("could not read config: %s", err.Error())
+		}
+		return ex
+	}
+
+	// The configured type doesn't restrict the extensions searched for.
+	if ex := read(); ex.Numberr != 7 {
+		t.Fatalf("invalid number: want %d, got %d", 7, ex.Numberr)
+	}
+
+	// Multiple matches are not an error, the first supported extension wins.
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"numberr": 8}`), 0o600); err != nil {
+		t.Fatalf("could not write test file: %s", err.Error())
+	}
+	if ex := read(); ex.Numberr != 8 {
+		t.Fatalf("invalid number: want %d, got %d", 8, ex.Numberr)
+	}
+
+	// Files without extension are read with the configured type.
+	bare := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bare, "config"), []byte("numberr: 9\n"), 0o600); err != nil {
+		t.Fatalf("could not write test file: %s", err.Error())
+	}
+	dir = bare
+	if ex := read(); ex.Numberr != 9 {
+		t.Fatalf("invalid number: want %d, got %d", 9, ex.Numberr)
+	}
+}
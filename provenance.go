@@ -0,0 +1,120 @@
+package configer
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Source names of the values that are not stored as layers.
+const (
+	sourceDefault = "default"
+	sourceFlag    = "flag"
+	sourceEnv     = "environment variable"
+)
+
+// redacted replaces secret values in the parser's output.
+const redacted = "[REDACTED]"
+
+// keySources returns the source that supplied the value of every config key
+// of the configuration, by key.
+func (p *configParser) keySources(opts []ConfigOption) map[string]string {
+	byKey := make(map[string]ConfigOption, len(opts))
+	for _, opt := range opts {
+		byKey[strings.ToLower(opt.ConfigKey)] = opt
+	}
+
+	// Layers with higher priority are checked first. For equal priorities,
+	// the layer added last takes precedence.
+	layers := make([]layer, len(p.layers))
+	copy(layers, p.layers)
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i].priority < layers[j].priority
+	})
+
+	sources := make(map[string]string)
+	for key := range flattenValues(p.viper.AllSettings()) {
+		if source, ok := p.keptSources[key]; ok {
+			sources[key] = source
+			continue
+		}
+		sources[key] = p.keySource(key, byKey[key], layers)
+	}
+	return sources
+}
+
+// keySource returns the source that supplied the value of a config key, using
+// the same precedence as the parser.
+func (p *configParser) keySource(key string, opt ConfigOption, layers []layer) string {
+	if opt.FlagName != "" {
+		if f := p.flags.Lookup(opt.FlagName); f != nil && f.Changed {
+			return sourceFlag + " --" + opt.FlagName
+		}
+	}
+
+	if opt.ConfigKey == "" {
+		opt.ConfigKey = key
+	}
+	for _, name := range p.optionEnvVars(opt) {
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			return sourceEnv + " " + name
+		}
+	}
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		if hasNestedKey(layers[i].values, key) {
+			return layers[i].name
+		}
+	}
+
+	if p.defaultFileKeys[key] {
+		return p.defaultFileName
+	}
+	return sourceDefault
+}
+
+// hasNestedKey returns whether the nested configuration map holds a value at
+// the config key, or at one of its parents. The latter is the case for keys
+// of maps and lists of structs.
+func hasNestedKey(values map[string]any, key string) bool {
+	if _, ok := getNestedValue(values, key); ok {
+		return true
+	}
+	for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key, ".") {
+		key = key[:i]
+		if value, ok := getNestedValue(values, key); ok {
+			_, isMap := toStringMap(value)
+			return !isMap
+		}
+	}
+	return false
+}
+
+// secretKeys returns the config keys whose values are sensitive: the keys of
+// the options marked as secret and the keys whose values were encrypted.
+func (p *configParser) secretKeys() map[string]bool {
+	secrets := make(map[string]bool, len(p.secrets)+len(p.encrypted))
+	for key := range p.secrets {
+		secrets[key] = true
+	}
+	for key := range p.encrypted {
+		secrets[key] = true
+	}
+	return secrets
+}
+
+// isSecretKey returns whether the value of a config key is sensitive. Keys
+// nested under a secret key are also secret.
+func isSecretKey(secrets map[string]bool, key string) bool {
+	key = strings.ToLower(key)
+	for {
+		if secrets[key] {
+			return true
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
@@ -0,0 +1,234 @@
+package configer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// ErrRestartRequired is returned when reloading the configuration changed
+// options which require a restart of the process.
+var ErrRestartRequired = errors.New("configuration changes require a restart")
+
+// ReloadPolicy defines how a Reloader handles the changes of options which
+// require a restart of the process.
+type ReloadPolicy int
+
+const (
+	// RejectRestartRequired rejects the new configuration if it changes an
+	// option which requires a restart.
+	RejectRestartRequired ReloadPolicy = iota
+	// ApplyReloadable applies the changes of the options which can be
+	// reloaded, and keeps the current values of the options which require a
+	// restart.
+	ApplyReloadable
+)
+
+// ReloadOptions configures how a Reloader reloads the configuration.
+type ReloadOptions struct {
+	// The signals that trigger a reload. Defaults to SIGHUP.
+	Signals []os.Signal
+	// If set, a reload is triggered every time a value is received, in
+	// addition to the signals. Allows triggering reloads without sending
+	// signals, e.g. in tests.
+	Trigger <-chan struct{}
+	// Called after every reload with the new config, or with the error that
+	// prevented the reload, in which case the config is nil.
+	OnReload func(config any, err error)
+	// Defines how changes of the options which require a restart are
+	// handled. Defaults to RejectRestartRequired.
+	Policy ReloadPolicy
+}
+
+// Reloader re-reads the configuration of a running process from all sources,
+// such as when a daemon receives SIGHUP. The new configuration replaces the
+// current one only if it was read and validated successfully, and it doesn't
+// change options which require a restart, unless the reload policy allows
+// it.
+//
+// It is safe for concurrent use.
+type Reloader struct {
+	loader  *Loader
+	options ReloadOptions
+
+	// Serializes reloads.
+	reloading sync.Mutex
+
+	mu     sync.RWMutex
+	parser *configParser
+	config any
+}
+
+// NewReloader returns a Reloader which reloads the configuration that was
+// loaded into configStruct via Load. configStruct must be a pointer to a
+// struct, and is the config returned by Config until the first successful
+// reload. Each reload creates a new struct, so that the structs handed out
+// are never modified.
+func (l *Loader) NewReloader(configStruct any, options ReloadOptions) (*Reloader, error) {
+	if t := reflect.TypeOf(configStruct); t == nil || t.Kind() != reflect.Pointer {
+		return nil, errors.New("config struct must be a pointer")
+	}
+	if len(options.Signals) == 0 {
+		options.Signals = []os.Signal{syscall.SIGHUP}
+	}
+	return &Reloader{
+		loader:  l,
+		options: options,
+		parser:  l.parser,
+		config:  configStruct,
+	}, nil
+}
+
+// Run reloads the configuration every time one of the signals is received,
+// the trigger fires or a watched source changes, until ctx is done. Watched
+// sources without a change handler are watched by Run until ctx is done.
+func (r *Reloader) Run(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, r.options.Signals...)
+	defer signal.Stop(signals)
+
+	changes := r.loader.parser.watchSourceChanges(ctx)
+	trigger := r.options.Trigger
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signals:
+			r.logger().Printf("[configer info] received %s, reloading configuration\n", sig)
+			r.Reload()
+		case <-changes:
+			r.logger().Println("[configer info] watched source changed, reloading configuration")
+			r.Reload()
+		case _, ok := <-trigger:
+			// Receiving from a nil channel blocks forever.
+			if !ok {
+				trigger = nil
+				continue
+			}
+			r.Reload()
+		}
+	}
+}
+
+// Reload re-reads the configuration from all sources and validates it. If
+// successful, the new configuration replaces the current one. The outcome is
+// logged and reported to the OnReload callback.
+func (r *Reloader) Reload() error {
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+
+	config, parser, err := r.load()
+	if err != nil {
+		r.logger().Printf("[configer warn] could not reload configuration: %s\n", err.Error())
+		if r.options.OnReload != nil {
+			r.options.OnReload(nil, err)
+		}
+		return err
+	}
+
+	r.mu.Lock()
+	r.parser = parser
+	r.config = config
+	r.mu.Unlock()
+
+	parser.log.Println("[configer info] reloaded configuration")
+	parser.publishFingerprint(r.loader.appOptions)
+	if r.options.OnReload != nil {
+		r.options.OnReload(config, nil)
+	}
+	return nil
+}
+
+// load reads the configuration with a new parser, configured the same way as
+// the parser of the loader. The write and diff flags are ignored, since they
+// only apply when the process starts.
+func (r *Reloader) load() (any, *configParser, error) {
+	l := r.loader
+	reloaded := &Loader{
+		parser:        newConfiguredParser(l.parser.flags, l.parserOptions...),
+		appOptions:    l.appOptions,
+		parserOptions: l.parserOptions,
+		readFlag:      l.readFlag,
+		typeFlag:      l.typeFlag,
+		stdin:         l.stdin,
+	}
+	if err := reloaded.load(); err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.RLock()
+	current := r.parser
+	config := reflect.New(reflect.TypeOf(r.config).Elem()).Interface()
+	r.mu.RUnlock()
+
+	if keys := restartRequiredChanges(current, reloaded.parser, l.appOptions); len(keys) != 0 {
+		if r.options.Policy != ApplyReloadable {
+			return nil, nil, fmt.Errorf("%w: %s", ErrRestartRequired, strings.Join(keys, ", "))
+		}
+		currentSources := current.keySources(l.appOptions)
+		for _, key := range keys {
+			reloaded.parser.viper.Set(key, current.viper.Get(key))
+			// The kept values still come from their previous sources.
+			prefix := strings.ToLower(key)
+			for k, source := range currentSources {
+				if k == prefix || strings.HasPrefix(k, prefix+".") {
+					reloaded.parser.keptSources[k] = source
+				}
+			}
+		}
+		// The values that were kept may violate the constraints of the
+		// options.
+		if err := reloaded.parser.validate(l.appOptions); err != nil {
+			return nil, nil, err
+		}
+		current.log.Printf("[configer warn] config keys %s changed, but require a restart to be applied\n", strings.Join(keys, ", "))
+	}
+	if err := reloaded.parser.unmarshal(config); err != nil {
+		return nil, nil, err
+	}
+	return config, reloaded.parser, nil
+}
+
+// restartRequiredChanges returns the config keys of the options which require
+// a restart, whose values differ between the two parsers.
+func restartRequiredChanges(current, reloaded *configParser, opts []ConfigOption) []string {
+	var keys []string
+	for _, opt := range opts {
+		if !opt.RestartRequired || opt.ConfigKey == "" {
+			continue
+		}
+		if !sameValue(current.viper.Get(opt.ConfigKey), reloaded.viper.Get(opt.ConfigKey), opt.Value, current.listSeparator) {
+			keys = append(keys, opt.ConfigKey)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Config returns a pointer to the current configuration struct.
+func (r *Reloader) Config() any {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.config
+}
+
+// Snapshot returns a read-only snapshot of the current configuration.
+func (r *Reloader) Snapshot() *Snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.parser.snapshot(r.loader.appOptions)
+}
+
+func (r *Reloader) logger() *log.Logger {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.parser.log
+}
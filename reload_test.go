@@ -0,0 +1,204 @@
+package configer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+type reloadResult struct {
+	config any
+	err    error
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yml")
+	if err := os.WriteFile(configFile, []byte("numberr: 13\n"), 0o600); err != nil {
+		t.Fatalf("could not write config file: %s", err.Error())
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	loader, err := RegisterFlags(flags, getyamlopts(), WithConfigFile(configFile), WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not register flags: %s", err.Error())
+	}
+	if err := flags.Parse(nil); err != nil {
+		t.Fatalf("could not parse flags: %s", err.Error())
+	}
+	initial := &Example1{}
+	if err := loader.Load(initial); err != nil {
+		t.Fatalf("could not load config: %s", err.Error())
+	}
+
+	trigger := make(chan struct{})
+	results := make(chan reloadResult, 1)
+	reloader, err := loader.NewReloader(initial, ReloadOptions{
+		Trigger: trigger,
+		OnReload: func(config any, err error) {
+			results <- reloadResult{config, err}
+		},
+	})
+	if err != nil {
+		t.Fatalf("could not create reloader: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Run(ctx)
+
+	reload := func() reloadResult {
+		trigger <- struct{}{}
+		select {
+		case result := <-results:
+			return result
+		case <-time.After(5 * time.Second):
+			t.Fatalf("config was not reloaded")
+		}
+		return reloadResult{}
+	}
+
+	if err := os.WriteFile(configFile, []byte("numberr: 14\n"), 0o600); err != nil {
+		t.Fatalf("could not write config file: %s", err.Error())
+	}
+	result := reload()
+	if result.err != nil {
+		t.Fatalf("could not reload config: %s", result.err.Error())
+	}
+	if n := result.config.(*Example1).Numberr; n != 14 {
+		t.Fatalf("invalid reloaded number: want %d, got %d", 14, n)
+	}
+	if initial.Numberr != 13 {
+		t.Fatalf("initial config was modified: %+v", initial)
+	}
+
+	if err := os.WriteFile(configFile, []byte("numberr: [\n"), 0o600); err != nil {
+		t.Fatalf("could not write config file: %s", err.Error())
+	}
+	if result := reload(); result.err == nil {
+		t.Fatalf("expected error when reloading an invalid config")
+	}
+	if n := reloader.Config().(*Example1).Numberr; n != 14 {
+		t.Fatalf("invalid config was applied: want number %d, got %d", 14, n)
+	}
+	if n := reloader.Snapshot().GetInt("numberr"); n != 14 {
+		t.Fatalf("invalid snapshot number: want %d, got %d", 14, n)
+	}
+}
+
+func TestReloaderRestartRequired(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yml")
+	if err := os.WriteFile(configFile, []byte("numberr: 13\nstringg: hello\n"), 0o600); err != nil {
+		t.Fatalf("could not write config file: %s", err.Error())
+	}
+
+	opts := getyamlopts()
+	opts[0].RestartRequired = true
+
+	newReloader := func(policy ReloadPolicy) *Reloader {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		loader, err := RegisterFlags(flags, opts, WithConfigFile(configFile), WithSupressLogs())
+		if err != nil {
+			t.Fatalf("could not register flags: %s", err.Error())
+		}
+		if err := flags.Parse(nil); err != nil {
+			t.Fatalf("could not parse flags: %s", err.Error())
+		}
+		if err := loader.Load(&Example1{}); err != nil {
+			t.Fatalf("could not load config: %s", err.Error())
+		}
+		reloader, err := loader.NewReloader(&Example1{}, ReloadOptions{Policy: policy})
+		if err != nil {
+			t.Fatalf("could not create reloader: %s", err.Error())
+		}
+		return reloader
+	}
+
+	rejecting := newReloader(RejectRestartRequired)
+	applying := newReloader(ApplyReloadable)
+
+	if err := os.WriteFile(configFile, []byte("numberr: 14\nstringg: changed\n"), 0o600); err != nil {
+		t.Fatalf("could not write config file: %s", err.Error())
+	}
+
+	err := rejecting.Reload()
+	if !errors.Is(err, ErrRestartRequired) || !strings.Contains(err.Error(), "numberr") {
+		t.Fatalf("expected restart required error naming numberr, got %v", err)
+	}
+
+	if err := applying.Reload(); err != nil {
+		t.Fatalf("could not reload config: %s", err.Error())
+	}
+	config := applying.Config().(*Example1)
+	if config.Numberr != 13 || config.Stringg != "changed" {
+		t.Fatalf("invalid reloaded config: %+v", config)
+	}
+
+	// The kept value is still attributed to the file it was read from, even
+	// though the file no longer sets it.
+	if err := os.WriteFile(configFile, []byte("stringg: again\n"), 0o600); err != nil {
+		t.Fatalf("could not write config file: %s", err.Error())
+	}
+	if err := applying.Reload(); err != nil {
+		t.Fatalf("could not reload config: %s", err.Error())
+	}
+	snapshot := applying.Snapshot()
+	if n := snapshot.GetInt("numberr"); n != 13 {
+		t.Fatalf("invalid kept number: want %d, got %d", 13, n)
+	}
+	if source := snapshot.Source("numberr"); source != configFile {
+		t.Fatalf("invalid source of kept number: want %s, got %s", configFile, source)
+	}
+	if source := snapshot.Source("stringg"); source != configFile {
+		t.Fatalf("invalid source of stringg: want %s, got %s", configFile, source)
+	}
+}
+
+func TestReloaderStdin(t *testing.T) {
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatalf("could not create stdin file: %s", err.Error())
+	}
+	defer stdin.Close()
+	if _, err := stdin.WriteString("numberr: 7\n"); err != nil {
+		t.Fatalf("could not write stdin file: %s", err.Error())
+	}
+	if _, err := stdin.Seek(0, 0); err != nil {
+		t.Fatalf("could not rewind stdin file: %s", err.Error())
+	}
+	old := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = old }()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	loader, err := RegisterFlags(flags, getyamlopts(), WithReadFlag(), WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not register flags: %s", err.Error())
+	}
+	if err := flags.Parse([]string{"--read-config", "-"}); err != nil {
+		t.Fatalf("could not parse flags: %s", err.Error())
+	}
+	ex := &Example1{}
+	if err := loader.Load(ex); err != nil {
+		t.Fatalf("could not load config: %s", err.Error())
+	}
+	reloader, err := loader.NewReloader(ex, ReloadOptions{})
+	if err != nil {
+		t.Fatalf("could not create reloader: %s", err.Error())
+	}
+
+	// Standard input is at EOF, so the content read initially is used.
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("could not reload config: %s", err.Error())
+	}
+	if n := reloader.Config().(*Example1).Numberr; n != 7 {
+		t.Fatalf("invalid reloaded number: want %d, got %d", 7, n)
+	}
+}
@@ -0,0 +1,242 @@
+package configer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotModified is returned by a RemoteStore if the entries it stores did not
+// change since the version identified by the index supplied to List.
+var ErrNotModified = errors.New("remote configuration not modified")
+
+// KVPair is a single entry of a remote key/value store.
+type KVPair struct {
+	Key   string
+	Value []byte
+}
+
+// RemoteStore is implemented by the remote key/value stores configuration can
+// be fetched from. HTTPStore implements a store exposing a Consul-style JSON
+// API.
+type RemoteStore interface {
+	// List returns the entries of the store, together with an index that
+	// identifies their version. The keys use the "/" separator for nested
+	// configuration keys, e.g. "server/port".
+	//
+	// If index is not empty, the store may block until its entries change
+	// from that version or ctx is done (long polling). ErrNotModified is
+	// returned if the entries did not change.
+	List(ctx context.Context, index string) ([]KVPair, string, error)
+}
+
+// RemoteOptions configures how configuration is fetched from a remote store.
+type RemoteOptions struct {
+	// Timeout of a single attempt to fetch the configuration when the config
+	// is created. Defaults to 5 seconds.
+	Timeout time.Duration
+	// The number of times fetching the configuration is retried if it fails.
+	Retries int
+	// The delay between two attempts. Defaults to 1 second.
+	RetryDelay time.Duration
+	// The file where the last configuration fetched from the store is cached.
+	// If the store is unreachable, the configuration is read from this file
+	// instead. May be empty if no snapshot should be kept.
+	CacheFile string
+	// If set, the store is polled for changes in the background after the
+	// config is created, until Context is done. Every change also reloads
+	// the configuration of a running Reloader.
+	Watch bool
+	// The delay between two polls. Stores which support long polling may use
+	// a short interval. Defaults to 30 seconds.
+	PollInterval time.Duration
+	// Called with the values of the store every time they change. If not
+	// set, the store is only polled while a Reloader is running, until the
+	// context of the Reloader is done.
+	OnChange func(values map[string]any)
+	// Bounds the lifetime of the background polling. Defaults to
+	// context.Background().
+	Context context.Context
+}
+
+// remoteSource fetches the configuration layer supplied by a remote store.
+type remoteSource struct {
+	store   RemoteStore
+	options RemoteOptions
+	log     *log.Logger
+
+	// The version of the last entries fetched from the store.
+	index string
+}
+
+func newRemoteSource(store RemoteStore, options RemoteOptions) *remoteSource {
+	if options.Timeout == 0 {
+		options.Timeout = 5 * time.Second
+	}
+	if options.RetryDelay == 0 {
+		options.RetryDelay = time.Second
+	}
+	if options.PollInterval == 0 {
+		options.PollInterval = 30 * time.Second
+	}
+	if options.Context == nil {
+		options.Context = context.Background()
+	}
+	return &remoteSource{
+		store:   store,
+		options: options,
+	}
+}
+
+// Name implements Source.
+func (s *remoteSource) Name() string {
+	if stringer, ok := s.store.(fmt.Stringer); ok {
+		return fmt.Sprintf("remote store %s", stringer.String())
+	}
+	return fmt.Sprintf("remote store %T", s.store)
+}
+
+// Load returns the values of the remote store, retrying the configured
+// number of times. If the store could not be reached, the values are read
+// from the cached snapshot, if one exists.
+func (s *remoteSource) Load(ctx context.Context) (map[string]any, error) {
+	var err error
+	for attempt := 0; attempt <= s.options.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(s.options.RetryDelay):
+			}
+		}
+
+		var pairs []KVPair
+		var index string
+
+		attemptCtx, cancel := context.WithTimeout(ctx, s.options.Timeout)
+		pairs, index, err = s.store.List(attemptCtx, "")
+		cancel()
+
+		if err == nil {
+			s.index = index
+			values := pairsToValues(pairs)
+			s.writeCache(values)
+			return values, nil
+		}
+		s.log.Printf("[configer warn] could not fetch configuration from %s (attempt %d): %s\n", s.Name(), attempt+1, err.Error())
+	}
+
+	if s.options.CacheFile != "" {
+		values, cacheErr := s.readCache()
+		if cacheErr == nil {
+			s.log.Printf("[configer warn] using cached configuration at %s\n", s.options.CacheFile)
+			return values, nil
+		}
+		if !os.IsNotExist(cacheErr) {
+			s.log.Printf("[configer warn] could not read cached configuration at %s: %s\n", s.options.CacheFile, cacheErr.Error())
+		}
+	}
+	return nil, fmt.Errorf("could not fetch configuration from %s: %w", s.Name(), err)
+}
+
+// Watch polls the remote store for changes until ctx is done, and calls
+// onChange with the new values.
+func (s *remoteSource) Watch(ctx context.Context, onChange func(values map[string]any)) error {
+	for {
+		pairs, index, err := s.store.List(ctx, s.index)
+		switch {
+		case err == nil:
+			s.index = index
+			values := pairsToValues(pairs)
+			s.writeCache(values)
+			onChange(values)
+		case errors.Is(err, ErrNotModified):
+		case ctx.Err() != nil:
+			return ctx.Err()
+		default:
+			s.log.Printf("[configer warn] could not poll configuration from %s: %s\n", s.Name(), err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.options.PollInterval):
+		}
+	}
+}
+
+func (s *remoteSource) setLogger(logger *log.Logger) {
+	s.log = logger
+}
+
+// writeCache stores a snapshot of the values fetched from the remote store.
+// Failing to write the snapshot is not fatal, since the remote values are
+// available.
+func (s *remoteSource) writeCache(values map[string]any) {
+	if s.options.CacheFile == "" {
+		return
+	}
+
+	data, err := json.Marshal(values)
+	if err == nil {
+		// Write to a temporary file first, to never leave a partially
+		// written snapshot behind.
+		tmp := s.options.CacheFile + ".tmp"
+		err = os.MkdirAll(filepath.Dir(s.options.CacheFile), 0o700)
+		if err == nil {
+			err = os.WriteFile(tmp, data, 0o600)
+		}
+		if err == nil {
+			err = os.Rename(tmp, s.options.CacheFile)
+		}
+	}
+	if err != nil {
+		s.log.Printf("[configer warn] could not cache remote configuration at %s: %s\n", s.options.CacheFile, err.Error())
+	}
+}
+
+// readCache reads the last snapshot of the values fetched from the remote
+// store.
+func (s *remoteSource) readCache() (map[string]any, error) {
+	data, err := os.ReadFile(s.options.CacheFile)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// pairsToValues converts the entries of a key/value store to a nested
+// configuration map, using the "/" separator for nested keys.
+func pairsToValues(pairs []KVPair) map[string]any {
+	values := make(map[string]any)
+	for _, pair := range pairs {
+		key := strings.Trim(strings.ToLower(pair.Key), "/")
+		// Folders are stored as keys ending in "/" and hold no value.
+		if key == "" || strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+
+		parts := strings.Split(key, "/")
+		current := values
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := current[part].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				current[part] = next
+			}
+			current = next
+		}
+		current[parts[len(parts)-1]] = string(pair.Value)
+	}
+	return values
+}
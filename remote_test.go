@@ -0,0 +1,329 @@
+package configer
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// newKVServer starts a server that stands in for a Consul-style key/value
+// store, serving the provided entries under the "app/" prefix.
+func newKVServer(t *testing.T, entries map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(consulIndexHeader, "1")
+		if r.URL.Query().Get("index") == "1" {
+			return
+		}
+		body := "["
+		for k, v := range entries {
+			if body != "[" {
+				body += ","
+			}
+			body += fmt.Sprintf(`{"Key":"app/%s","Value":"%s"}`, k, base64.StdEncoding.EncodeToString([]byte(v)))
+		}
+		body += "]"
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReadRemoteStore(t *testing.T) {
+	f, dir := initFile(t, "test.yml", []byte("numberr: 13\n"))
+	defer f.Close()
+
+	server := newKVServer(t, map[string]string{
+		"numberr":   "20",
+		"stringg":   "remote",
+		"durationn": "1m",
+	})
+
+	resetFlags(t)
+
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithConfigName("test"),
+		WithConfigType("yml"),
+		WithConfigPath(dir),
+		WithRemoteStore(&HTTPStore{URL: server.URL, Prefix: "app/"}, RemoteOptions{}),
+		WithSupressLogs())
+
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+
+	checkExample1(t, ex, Example1{
+		Numberr:   13,
+		Stringg:   "remote",
+		Booll:     false,
+		Durationn: time.Minute,
+	})
+}
+
+func TestReadRemoteStoreCache(t *testing.T) {
+	server := newKVServer(t, map[string]string{
+		"stringg": "cached",
+	})
+	store := &HTTPStore{URL: server.URL, Prefix: "app/"}
+	options := RemoteOptions{
+		CacheFile:  filepath.Join(t.TempDir(), "remote.json"),
+		RetryDelay: time.Millisecond,
+		Retries:    1,
+	}
+
+	resetFlags(t)
+
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithConfigName("garbage"),
+		WithRemoteStore(store, options),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+
+	// The remote is no longer reachable, so the cached snapshot is used.
+	server.Close()
+	resetFlags(t)
+
+	ex = Example1{}
+	err = NewConfig(&ex, getyamlopts(),
+		WithConfigName("garbage"),
+		WithRemoteStore(store, options),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+
+	if ex.Stringg != "cached" {
+		t.Fatalf("invalid string: want %s, got %s", "cached", ex.Stringg)
+	}
+}
+
+func TestHTTPStoreLongPolling(t *testing.T) {
+	var mu sync.Mutex
+	index, value := 1, "first"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("index") != "" && query.Get("wait") != "1s" {
+			t.Errorf("invalid wait time: %q", query.Get("wait"))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set(consulIndexHeader, fmt.Sprint(index))
+		fmt.Fprintf(w, `[{"Key":"app/stringg","Value":"%s"}]`, base64.StdEncoding.EncodeToString([]byte(value)))
+	}))
+	t.Cleanup(server.Close)
+	store := &HTTPStore{URL: server.URL, Prefix: "app/", Wait: time.Second}
+
+	pairs, idx, err := store.List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("could not list entries: %s", err.Error())
+	}
+	if idx != "1" || len(pairs) != 1 || string(pairs[0].Value) != "first" {
+		t.Fatalf("invalid entries at index %s: %v", idx, pairs)
+	}
+
+	// The blocking query expired without changes.
+	if _, _, err := store.List(context.Background(), idx); !errors.Is(err, ErrNotModified) {
+		t.Fatalf("expected ErrNotModified, got %v", err)
+	}
+
+	mu.Lock()
+	index, value = 2, "second"
+	mu.Unlock()
+	pairs, idx, err = store.List(context.Background(), idx)
+	if err != nil {
+		t.Fatalf("could not list entries: %s", err.Error())
+	}
+	if idx != "2" || len(pairs) != 1 || string(pairs[0].Value) != "second" {
+		t.Fatalf("invalid entries at index %s: %v", idx, pairs)
+	}
+}
+
+func TestHTTPStoreETag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("index") != "" {
+			t.Errorf("unexpected index query parameter for etag store")
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprintf(w, `[{"Key":"app/stringg","Value":"%s"}]`, base64.StdEncoding.EncodeToString([]byte("etag")))
+	}))
+	t.Cleanup(server.Close)
+	store := &HTTPStore{URL: server.URL, Prefix: "app/"}
+
+	pairs, idx, err := store.List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("could not list entries: %s", err.Error())
+	}
+	if idx != `"v1"` || len(pairs) != 1 {
+		t.Fatalf("invalid entries at index %s: %v", idx, pairs)
+	}
+	if _, _, err := store.List(context.Background(), idx); !errors.Is(err, ErrNotModified) {
+		t.Fatalf("expected ErrNotModified, got %v", err)
+	}
+}
+
+func TestReadRemoteStoreTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	resetFlags(t)
+	withArgs(t)
+
+	start := time.Now()
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithConfigName("garbage"),
+		WithRemoteStore(&HTTPStore{URL: server.URL}, RemoteOptions{
+			Timeout:    50 * time.Millisecond,
+			Retries:    1,
+			RetryDelay: time.Millisecond,
+		}),
+		WithSupressLogs())
+	if err == nil {
+		t.Fatalf("expected error when the store times out")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("attempts were not bounded by the timeout, took %v", elapsed)
+	}
+}
+
+func TestWatchRemoteStore(t *testing.T) {
+	var mu sync.Mutex
+	index, value := 1, "first"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set(consulIndexHeader, fmt.Sprint(index))
+		fmt.Fprintf(w, `[{"Key":"app/stringg","Value":"%s"}]`, base64.StdEncoding.EncodeToString([]byte(value)))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan map[string]any, 1)
+
+	resetFlags(t)
+	withArgs(t)
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithConfigName("garbage"),
+		WithRemoteStore(&HTTPStore{URL: server.URL, Prefix: "app/", Wait: time.Millisecond}, RemoteOptions{
+			Watch:        true,
+			PollInterval: 10 * time.Millisecond,
+			OnChange: func(values map[string]any) {
+				select {
+				case changes <- values:
+				default:
+				}
+			},
+			Context: ctx,
+		}),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+	if ex.Stringg != "first" {
+		t.Fatalf("invalid string: want %s, got %s", "first", ex.Stringg)
+	}
+
+	mu.Lock()
+	index, value = 2, "second"
+	mu.Unlock()
+
+	select {
+	case values := <-changes:
+		if values["stringg"] != "second" {
+			t.Fatalf("invalid changed values: %v", values)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("change of the store was not detected")
+	}
+}
+
+func TestWatchRemoteStoreReload(t *testing.T) {
+	var mu sync.Mutex
+	index, value := 1, "first"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set(consulIndexHeader, fmt.Sprint(index))
+		fmt.Fprintf(w, `[{"Key":"app/stringg","Value":"%s"}]`, base64.StdEncoding.EncodeToString([]byte(value)))
+	}))
+	t.Cleanup(server.Close)
+
+	// Neither RemoteOptions.Context nor OnChange is set, so the store is
+	// only watched by the reloader, until its context is done.
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	loader, err := RegisterFlags(flags, getyamlopts(),
+		WithConfigName("garbage"),
+		WithRemoteStore(&HTTPStore{URL: server.URL, Prefix: "app/", Wait: time.Millisecond}, RemoteOptions{
+			Watch:        true,
+			PollInterval: 10 * time.Millisecond,
+		}),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not register flags: %s", err.Error())
+	}
+	if err := flags.Parse(nil); err != nil {
+		t.Fatalf("could not parse flags: %s", err.Error())
+	}
+	initial := &Example1{}
+	if err := loader.Load(initial); err != nil {
+		t.Fatalf("could not load config: %s", err.Error())
+	}
+	if initial.Stringg != "first" {
+		t.Fatalf("invalid string: want %s, got %s", "first", initial.Stringg)
+	}
+
+	results := make(chan reloadResult, 1)
+	reloader, err := loader.NewReloader(initial, ReloadOptions{
+		OnReload: func(config any, err error) {
+			select {
+			case results <- reloadResult{config, err}:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("could not create reloader: %s", err.Error())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go reloader.Run(ctx)
+
+	mu.Lock()
+	index, value = 2, "second"
+	mu.Unlock()
+
+	select {
+	case result := <-results:
+		if result.err != nil {
+			t.Fatalf("could not reload config: %s", result.err.Error())
+		}
+		if s := result.config.(*Example1).Stringg; s != "second" {
+			t.Fatalf("invalid reloaded string: want %s, got %s", "second", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("change of the store was not detected")
+	}
+}
@@ -0,0 +1,130 @@
+package configer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// configCandidates returns the names of the config files the parser searches
+// for in each config path, in order of preference.
+//
+// Unless the candidates were set explicitly, the config name is searched
+// with every supported extension, followed by the name without extension, in
+// the same order as viper.
+func (p *configParser) configCandidates() []string {
+	if len(p.configCandidateNames) != 0 {
+		return p.configCandidateNames
+	}
+	name := p.configBaseName()
+	candidates := make([]string, 0, len(viper.SupportedExts)+1)
+	for _, ext := range viper.SupportedExts {
+		candidates = append(candidates, name+"."+ext)
+	}
+	return append(candidates, name)
+}
+
+// findConfigFile searches the directories in order for the candidate config
+// files, and returns the path of the candidate found in the first directory
+// containing any. An empty path is returned if no candidate exists.
+//
+// Multiple candidates set via WithConfigCandidates found in the same
+// directory are reported as an error, since picking one of them would
+// silently ignore the others. For the default candidates, the first one is
+// used and a warning is logged, as viper did.
+func (p *configParser) findConfigFile(dirs []string) (string, error) {
+	for _, dir := range dirs {
+		var found []string
+		for _, name := range p.configCandidates() {
+			candidate := filepath.Join(dir, name)
+			stat, err := os.Stat(candidate)
+			switch {
+			case err == nil && !stat.IsDir():
+				p.debugf("found config file at %s", candidate)
+				found = append(found, candidate)
+			case err == nil, os.IsNotExist(err):
+				p.debugf("no config file at %s", candidate)
+			default:
+				return "", fmt.Errorf("could not retrieve stats for %s: %w", candidate, err)
+			}
+		}
+
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			if len(p.configCandidateNames) != 0 {
+				return "", fmt.Errorf("ambiguous config files in %s: %s", dir, strings.Join(found, ", "))
+			}
+			p.log.Printf("[configer warn] multiple config files in %s, using %s: %s\n", dir, found[0], strings.Join(found, ", "))
+			return found[0], nil
+		}
+	}
+	return "", nil
+}
+
+// expandPath expands a leading "~" to the home directory of the current user,
+// and the environment variables referenced in path. The path is returned
+// unchanged if the home directory cannot be determined.
+func expandPath(path string) string {
+	path = os.ExpandEnv(path)
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	// Joining would drop a trailing separator, which is meaningful for the
+	// write flag.
+	return home + path[1:]
+}
+
+// standardConfigPaths returns the standard directories containing the config
+// files of the app, in order: the XDG config directory, the user config
+// directory, the system config directory and the directory of the running
+// executable. Directories which cannot be determined are skipped. If app is
+// empty, it is derived from the name of the executable.
+func standardConfigPaths(app string) []string {
+	if app == "" {
+		app = appName()
+	}
+
+	var paths []string
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		paths = append(paths, filepath.Join(xdg, app))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", app))
+	}
+	paths = append(paths, filepath.Join("/etc", app))
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		paths = append(paths, filepath.Dir(exe))
+	}
+
+	// XDG_CONFIG_HOME usually points to ~/.config.
+	unique := paths[:0]
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+	return unique
+}
+
+// appName returns the name of the running executable, without extension.
+func appName() string {
+	name := filepath.Base(os.Args[0])
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
@@ -0,0 +1,185 @@
+package configer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// Snapshot is a read-only view of the configuration obtained after merging
+// all sources. It is safe for concurrent use.
+type Snapshot struct {
+	// Nested configuration values, with lowercase keys. Never modified after
+	// the snapshot is created.
+	settings      map[string]any
+	listSeparator string
+
+	// The source of every config key, and the keys whose values are
+	// sensitive.
+	sources map[string]string
+	secrets map[string]bool
+
+	// The default values of the options, by config key, whose types are
+	// used to normalize the fingerprint.
+	types map[string]any
+}
+
+// snapshot returns a snapshot of the parser's current configuration.
+func (p *configParser) snapshot(opts []ConfigOption) *Snapshot {
+	return &Snapshot{
+		settings:      p.viper.AllSettings(),
+		listSeparator: p.listSeparator,
+		sources:       p.keySources(opts),
+		secrets:       p.secretKeys(),
+		types:         optionTypes(opts),
+	}
+}
+
+// Get returns the value of a config key, or nil if the key is not set. Maps
+// and slices must not be modified.
+func (s *Snapshot) Get(key string) any {
+	value, _ := getNestedValue(s.settings, key)
+	return value
+}
+
+// Source returns the source that supplied the value of a config key, such as
+// the path of a config file, a flag, an environment variable or "default".
+// It returns an empty string if the key has no value.
+func (s *Snapshot) Source(key string) string {
+	return s.sources[strings.ToLower(key)]
+}
+
+// IsSecret returns whether the value of a config key is sensitive, i.e. it
+// belongs to an option marked as secret or it was encrypted.
+func (s *Snapshot) IsSecret(key string) bool {
+	return isSecretKey(s.secrets, key)
+}
+
+// IsSet returns whether the config key has a value, including default
+// values.
+func (s *Snapshot) IsSet(key string) bool {
+	_, ok := getNestedValue(s.settings, key)
+	return ok
+}
+
+// GetString returns the value of a config key as a string.
+func (s *Snapshot) GetString(key string) string {
+	return cast.ToString(s.Get(key))
+}
+
+// GetBool returns the value of a config key as a bool.
+func (s *Snapshot) GetBool(key string) bool {
+	return cast.ToBool(s.Get(key))
+}
+
+// GetInt returns the value of a config key as an int.
+func (s *Snapshot) GetInt(key string) int {
+	return cast.ToInt(s.Get(key))
+}
+
+// GetFloat64 returns the value of a config key as a float64.
+func (s *Snapshot) GetFloat64(key string) float64 {
+	return cast.ToFloat64(s.Get(key))
+}
+
+// GetDuration returns the value of a config key as a duration.
+func (s *Snapshot) GetDuration(key string) time.Duration {
+	return cast.ToDuration(s.Get(key))
+}
+
+// GetStringSlice returns the value of a config key as a slice of strings.
+// Strings are split using the list separator.
+func (s *Snapshot) GetStringSlice(key string) []string {
+	if value, ok := s.Get(key).(string); ok {
+		if value == "" {
+			return []string{}
+		}
+		return strings.Split(value, s.listSeparator)
+	}
+	return cast.ToStringSlice(s.Get(key))
+}
+
+// GetStringMapString returns the value of a config key as a map of strings.
+func (s *Snapshot) GetStringMapString(key string) map[string]string {
+	return cast.ToStringMapString(s.Get(key))
+}
+
+// AllKeys returns all config keys that have a value, sorted. Nested keys use
+// the "." separator.
+func (s *Snapshot) AllKeys() []string {
+	flat := flattenValues(s.settings)
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// AllSettings returns a copy of all configuration values, as nested maps.
+func (s *Snapshot) AllSettings() map[string]any {
+	settings := make(map[string]any, len(s.settings))
+	mergeValues(settings, s.settings)
+	return settings
+}
+
+// Sub returns a snapshot of the values under a config key, e.g. Sub("cache")
+// for the keys "cache.*". If the key holds no nested values, the returned
+// snapshot is empty.
+func (s *Snapshot) Sub(key string) *Snapshot {
+	value, _ := getNestedValue(s.settings, key)
+	settings, ok := toStringMap(value)
+	if !ok {
+		settings = make(map[string]any)
+	}
+
+	// Keep the sources, secrets and types of the nested keys, relative to the
+	// key.
+	prefix := strings.ToLower(key) + "."
+	sources := make(map[string]string)
+	for k, source := range s.sources {
+		if strings.HasPrefix(k, prefix) {
+			sources[strings.TrimPrefix(k, prefix)] = source
+		}
+	}
+	secrets := make(map[string]bool)
+	for k := range s.secrets {
+		if strings.HasPrefix(k, prefix) {
+			secrets[strings.TrimPrefix(k, prefix)] = true
+		}
+	}
+	types := make(map[string]any)
+	for k, prototype := range s.types {
+		if strings.HasPrefix(k, prefix) {
+			types[strings.TrimPrefix(k, prefix)] = prototype
+		}
+	}
+	// Everything under a secret key is secret.
+	if isSecretKey(s.secrets, key) {
+		for k := range flattenValues(settings) {
+			secrets[k] = true
+		}
+	}
+
+	return &Snapshot{
+		settings:      settings,
+		listSeparator: s.listSeparator,
+		sources:       sources,
+		secrets:       secrets,
+		types:         types,
+	}
+}
+
+// Unmarshal decodes the snapshot's values to the provided struct, the same
+// way NewConfig does.
+func (s *Snapshot) Unmarshal(configStruct interface{}) error {
+	v := viper.New()
+	if err := v.MergeConfigMap(s.AllSettings()); err != nil {
+		return fmt.Errorf("could not read snapshot: %w", err)
+	}
+	return v.Unmarshal(configStruct, decoderOptions(s.listSeparator))
+}
@@ -0,0 +1,134 @@
+package configer
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// Source is implemented by custom configuration providers, which can be added
+// to the parser via WithSource. The values of a source are merged with the
+// values of the other sources according to its priority.
+type Source interface {
+	// Name identifies the source in logs and errors.
+	Name() string
+	// Load returns the configuration values supplied by the source. Nested
+	// configuration keys are represented as nested maps, e.g. the value of
+	// "server.port" is stored at values["server"]["port"].
+	Load(ctx context.Context) (map[string]any, error)
+}
+
+// Watcher may be implemented by sources that are able to detect changes to
+// their values.
+type Watcher interface {
+	// Watch blocks until ctx is done, and calls onChange with the new values
+	// of the source every time they change.
+	Watch(ctx context.Context, onChange func(values map[string]any)) error
+}
+
+// loggingSource is implemented by the built-in sources, which log through the
+// parser's logger.
+type loggingSource interface {
+	setLogger(*log.Logger)
+}
+
+// sourceEntry holds a source added to the parser, and how it is watched.
+type sourceEntry struct {
+	source   Source
+	priority int
+
+	// Whether the source is watched for changes after the config is
+	// created. If onChange is nil, the parser's change handler is used, and
+	// the source is only watched by a running Reloader if there is none.
+	watch    bool
+	onChange func(values map[string]any)
+	watchCtx context.Context
+	// Whether the source is already being watched.
+	watching bool
+}
+
+// loadSources loads the values of every source added to the parser, and
+// stores them as layers.
+func (p *configParser) loadSources(ctx context.Context) error {
+	for _, entry := range p.sources {
+		if s, ok := entry.source.(loggingSource); ok {
+			s.setLogger(p.log)
+		}
+		values, err := entry.source.Load(ctx)
+		if err != nil {
+			return fmt.Errorf("could not load source %s: %w", entry.source.Name(), err)
+		}
+		p.addLayer(entry.source.Name(), entry.priority, values)
+		p.log.Printf("[configer info] loaded config from %s\n", entry.source.Name())
+	}
+	return nil
+}
+
+// watchSources starts watching the sources that were configured to be
+// watched in the background.
+func (p *configParser) watchSources() {
+	for i := range p.sources {
+		entry := &p.sources[i]
+		if !entry.watch || entry.watching {
+			continue
+		}
+
+		name := entry.source.Name()
+		onChange := entry.onChange
+		if onChange == nil {
+			// Sources are not watched unless a handler was provided.
+			if p.onSourceChange == nil {
+				continue
+			}
+			onChange = func(values map[string]any) {
+				p.onSourceChange(name, values)
+			}
+		}
+		ctx := entry.watchCtx
+		if ctx == nil {
+			ctx = p.watchCtx
+		}
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		p.watchSource(ctx, entry, onChange)
+	}
+}
+
+// watchSourceChanges starts watching the sources configured to be watched
+// which have no change handler, until ctx is done, and returns the channel
+// notified every time any watched source changes.
+func (p *configParser) watchSourceChanges(ctx context.Context) <-chan struct{} {
+	for i := range p.sources {
+		entry := &p.sources[i]
+		if entry.watch && !entry.watching {
+			p.watchSource(ctx, entry, nil)
+		}
+	}
+	return p.sourceChanges
+}
+
+// watchSource watches a source in the background until ctx is done. Every
+// change is passed to onChange, if not nil, and notified on the parser's
+// change channel, such that a running Reloader reloads the configuration.
+func (p *configParser) watchSource(ctx context.Context, entry *sourceEntry, onChange func(values map[string]any)) {
+	entry.watching = true
+	name := entry.source.Name()
+	watcher := entry.source.(Watcher)
+	go func() {
+		err := watcher.Watch(ctx, func(values map[string]any) {
+			p.log.Printf("[configer info] config changed in %s\n", name)
+			if onChange != nil {
+				onChange(values)
+			}
+			// A pending notification already triggers a reload.
+			select {
+			case p.sourceChanges <- struct{}{}:
+			default:
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			p.log.Printf("[configer warn] stopped watching %s: %s\n", name, err.Error())
+		}
+	}()
+}
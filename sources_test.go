@@ -0,0 +1,153 @@
+package configer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// staticSource is a source with fixed values.
+type staticSource map[string]any
+
+func (s staticSource) Name() string {
+	return "static"
+}
+
+func (s staticSource) Load(ctx context.Context) (map[string]any, error) {
+	return s, nil
+}
+
+// watchedSource is a source whose values change every time they are set.
+type watchedSource struct {
+	mu      sync.Mutex
+	values  map[string]any
+	changed chan struct{}
+}
+
+func (s *watchedSource) Name() string {
+	return "watched"
+}
+
+func (s *watchedSource) Load(ctx context.Context) (map[string]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values, nil
+}
+
+func (s *watchedSource) Watch(ctx context.Context, onChange func(values map[string]any)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.changed:
+			values, _ := s.Load(ctx)
+			onChange(values)
+		}
+	}
+}
+
+func (s *watchedSource) set(values map[string]any) {
+	s.mu.Lock()
+	s.values = values
+	s.mu.Unlock()
+	s.changed <- struct{}{}
+}
+
+func TestReadCustomSource(t *testing.T) {
+	f, dir := initFile(t, "test.yml", example1)
+	defer f.Close()
+
+	resetFlags(t)
+
+	ex := Example1{}
+	err := NewConfig(&ex, getyamlopts(),
+		WithConfigName("test"),
+		WithConfigType("yml"),
+		WithConfigPath(dir),
+		WithSource(staticSource{"numberr": 20, "stringg": "below"}, PriorityFile-1),
+		WithSource(staticSource{"durationn": "1m", "booll": false}, PriorityFile+1),
+		WithSupressLogs())
+
+	if err != nil {
+		t.Fatalf("call to new config failed: %s", err.Error())
+	}
+
+	checkExample1(t, ex, Example1{
+		Numberr:   13,
+		Stringg:   "hello",
+		Booll:     false,
+		Durationn: time.Minute,
+	})
+}
+
+func TestReloadWatchedSource(t *testing.T) {
+	src := &watchedSource{
+		values:  map[string]any{"numberr": 20},
+		changed: make(chan struct{}),
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	loader, err := RegisterFlags(flags, getyamlopts(),
+		WithConfigName("garbage"),
+		WithSource(src, PriorityFile),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not register flags: %s", err.Error())
+	}
+	if err := flags.Parse(nil); err != nil {
+		t.Fatalf("could not parse flags: %s", err.Error())
+	}
+	initial := &Example1{}
+	if err := loader.Load(initial); err != nil {
+		t.Fatalf("could not load config: %s", err.Error())
+	}
+
+	results := make(chan reloadResult, 1)
+	reloader, err := loader.NewReloader(initial, ReloadOptions{
+		OnReload: func(config any, err error) {
+			results <- reloadResult{config, err}
+		},
+	})
+	if err != nil {
+		t.Fatalf("could not create reloader: %s", err.Error())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go reloader.Run(ctx)
+
+	change := func(values map[string]any) reloadResult {
+		src.set(values)
+		select {
+		case result := <-results:
+			return result
+		case <-time.After(5 * time.Second):
+			t.Fatalf("config was not reloaded")
+		}
+		return reloadResult{}
+	}
+
+	result := change(map[string]any{"numberr": 21})
+	if result.err != nil {
+		t.Fatalf("could not reload config: %s", result.err.Error())
+	}
+	if n := result.config.(*Example1).Numberr; n != 21 {
+		t.Fatalf("invalid reloaded number: want %d, got %d", 21, n)
+	}
+	snapshot := reloader.Snapshot()
+	if n := snapshot.GetInt("numberr"); n != 21 {
+		t.Fatalf("invalid snapshot number: want %d, got %d", 21, n)
+	}
+	if source := snapshot.Source("numberr"); source != "watched" {
+		t.Fatalf("invalid source: want %s, got %s", "watched", source)
+	}
+
+	if result := change(map[string]any{"numberr": "many"}); result.err == nil {
+		t.Fatalf("expected error when reloading an invalid config")
+	}
+	if n := reloader.Config().(*Example1).Numberr; n != 21 {
+		t.Fatalf("invalid config was applied: want number %d, got %d", 21, n)
+	}
+}
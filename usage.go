@@ -0,0 +1,162 @@
+package configer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// generalGroup groups the options whose config key has a single level, or
+// that have no config key.
+const generalGroup = "general"
+
+// WriteUsage writes a description of every config option to w, including
+// the flag, environment variable and config key it can be set with, together
+// with the locations where the parser looks for the configuration file.
+// Options are grouped by the top-level part of their config key.
+func (l *Loader) WriteUsage(w io.Writer) {
+	l.parser.writeUsage(w, l.appOptions)
+}
+
+// writeUsage writes the usage of the config options to w.
+func (p *configParser) writeUsage(w io.Writer, appOptions []ConfigOption) {
+	fmt.Fprintf(w, "Usage of %s:\n", filepath.Base(os.Args[0]))
+
+	groups := make(map[string][]ConfigOption)
+	var names []string
+	for _, opt := range appOptions {
+		group := generalGroup
+		if before, _, ok := strings.Cut(opt.ConfigKey, "."); ok {
+			group = before
+		}
+		if _, ok := groups[group]; !ok {
+			names = append(names, group)
+		}
+		groups[group] = append(groups[group], opt)
+	}
+
+	// The general options are displayed first.
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == generalGroup || names[j] == generalGroup {
+			return names[i] == generalGroup
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		fmt.Fprintf(w, "\n%s:\n", name)
+		for _, opt := range groups[name] {
+			p.writeOptionUsage(w, opt)
+		}
+	}
+
+	if len(p.groups) != 0 {
+		fmt.Fprintf(w, "\nconstraints:\n")
+		for _, group := range p.groups {
+			fmt.Fprintf(w, "  %s\n", group.usage())
+		}
+	}
+
+	if flags := p.configFileFlags(); len(flags) != 0 {
+		fmt.Fprintf(w, "\nconfig file flags:\n")
+		for _, flag := range flags {
+			fmt.Fprintf(w, "      %s\n", flag)
+		}
+	}
+
+	fmt.Fprintf(w, "\nconfig file:\n")
+	if p.configFile != "" {
+		fmt.Fprintf(w, "  file:         %s\n", p.configFile)
+		return
+	}
+	name := strings.Join(p.configCandidateNames, ", ")
+	if name == "" {
+		name = p.configBaseName() + ".{" + strings.Join(viper.SupportedExts, ",") + "}"
+	}
+	fmt.Fprintf(w, "  name:         %s\n", name)
+	paths := "none"
+	if len(p.configPaths) != 0 {
+		paths = strings.Join(p.configPaths, ", ")
+	}
+	fmt.Fprintf(w, "  search paths: %s\n", paths)
+}
+
+// configFileFlags returns the usage of the flags defined by the parser
+// itself, in the order they are defined.
+func (p *configParser) configFileFlags() []string {
+	var flags []string
+	if p.readFlag {
+		flags = append(flags, "--"+readFlagName()+" string")
+	}
+	if p.writeFlag {
+		flags = append(flags,
+			"--"+writeFlagName()+" string",
+			"--"+forceFlagName(),
+			"--"+writeModeFlagName()+" string")
+	}
+	if p.diffFlag {
+		flags = append(flags, "--"+diffFlagName()+" string")
+	}
+	if p.configTypeFlag {
+		flags = append(flags, "--"+configTypeFlagName()+" string")
+	}
+	return flags
+}
+
+// writeOptionUsage writes the usage of a single config option to w.
+func (p *configParser) writeOptionUsage(w io.Writer, opt ConfigOption) {
+	var flag string
+	switch {
+	case opt.FlagName == "":
+		flag = "(no flag)"
+	case opt.Shorthand != "":
+		flag = fmt.Sprintf("-%s, --%s", opt.Shorthand, opt.FlagName)
+	default:
+		flag = fmt.Sprintf("    --%s", opt.FlagName)
+	}
+	fmt.Fprintf(w, "  %s %s\n", flag, optionType(opt))
+
+	if opt.Usage != "" {
+		fmt.Fprintf(w, "        %s\n", opt.Usage)
+	}
+	if opt.ConfigKey != "" {
+		fmt.Fprintf(w, "        key: %s, env: %s\n", opt.ConfigKey, p.optionEnvNames(opt))
+	}
+	if opt.RestartRequired {
+		fmt.Fprintf(w, "        requires restart\n")
+	}
+	if opt.Required {
+		fmt.Fprintf(w, "        required\n")
+		return
+	}
+	fmt.Fprintf(w, "        default: %s\n", formatDefault(opt.Value))
+}
+
+// optionType returns the name of the type of a config option's value, using
+// the same names as the flags.
+func optionType(opt ConfigOption) string {
+	switch opt.Value.(type) {
+	case time.Duration:
+		return "duration"
+	case []byte:
+		return "bytesHex"
+	}
+	return fmt.Sprintf("%T", opt.Value)
+}
+
+// formatDefault formats the default value of a config option.
+func formatDefault(value any) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		return fmt.Sprintf("%X", v)
+	}
+	return fmt.Sprintf("%v", value)
+}
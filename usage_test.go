@@ -0,0 +1,82 @@
+package configer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestWriteUsage(t *testing.T) {
+	opts := []ConfigOption{
+		{FlagName: "insecure", Value: false, ConfigKey: "insecure",
+			Usage: "Whether TLS is disabled."},
+		{FlagName: "server-port", Shorthand: "p", Value: 8080, ConfigKey: "server.port",
+			Usage: "The server port."},
+		{FlagName: "server-timeout", Value: time.Minute, ConfigKey: "server.timeout",
+			RestartRequired: true},
+		{FlagName: "db-password", Value: "", ConfigKey: "db.password",
+			Required: true},
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	loader, err := RegisterFlags(flags, opts,
+		WithEnvPrefix("DEMO"),
+		WithEnvKeyReplacer("__"),
+		WithConfigCandidates("config.yaml", "app.toml"),
+		WithConfigPath("/etc/demo"),
+		WithConfigPath("./config"),
+		WithReadFlag(),
+		WithWriteFlag(),
+		WithDiffFlag(),
+		WithConfigTypeFlag(),
+		WithSupressLogs())
+	if err != nil {
+		t.Fatalf("could not register flags: %s", err.Error())
+	}
+
+	var out strings.Builder
+	loader.WriteUsage(&out)
+	usage := out.String()
+
+	expected := []string{
+		"key: server.port, env: DEMO_SERVER__PORT",
+		"key: db.password, env: DEMO_DB__PASSWORD",
+		"key: insecure, env: DEMO_INSECURE",
+		"-p, --server-port int",
+		"default: 1m0s",
+		"requires restart",
+		"required",
+		"name:         config.yaml, app.toml",
+		"search paths: /etc/demo, ./config",
+	}
+	for _, s := range expected {
+		if !strings.Contains(usage, s) {
+			t.Fatalf("usage does not contain %q:\n%s", s, usage)
+		}
+	}
+
+	// Groups are ordered by top-level key, with the general options first.
+	general := strings.Index(usage, "\ngeneral:\n")
+	db := strings.Index(usage, "\ndb:\n")
+	server := strings.Index(usage, "\nserver:\n")
+	if general == -1 || db == -1 || server == -1 || !(general < db && db < server) {
+		t.Fatalf("options are not grouped by top-level key:\n%s", usage)
+	}
+	if port := strings.Index(usage, "--server-port"); port < server {
+		t.Fatalf("server option is not in the server group:\n%s", usage)
+	}
+
+	// Every flag defined by the parser is listed.
+	flags.VisitAll(func(f *pflag.Flag) {
+		for _, opt := range opts {
+			if opt.FlagName == f.Name {
+				return
+			}
+		}
+		if !strings.Contains(usage, "--"+f.Name) {
+			t.Fatalf("usage does not list the flag --%s:\n%s", f.Name, usage)
+		}
+	})
+}
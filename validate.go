@@ -0,0 +1,84 @@
+package configer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// validate checks the configuration obtained after merging all sources, and
+// returns an error listing every problem found.
+func (p *configParser) validate(opts []ConfigOption) error {
+	var errs []error
+
+	layers := p.mergedLayers()
+	for _, opt := range opts {
+		if opt.Required && !p.isSet(opt, layers) {
+			errs = append(errs, fmt.Errorf("missing required option %s: %s", opt.ConfigKey, p.describeSources(opt)))
+		}
+	}
+
+	byKey := make(map[string]ConfigOption, len(opts))
+	for _, opt := range opts {
+		byKey[strings.ToLower(opt.ConfigKey)] = opt
+	}
+	isSet := func(key string) bool {
+		opt, ok := byKey[strings.ToLower(key)]
+		if !ok {
+			// Keys not associated with an option may still be set.
+			opt = ConfigOption{ConfigKey: key}
+		}
+		return p.isSet(opt, layers)
+	}
+	for _, group := range p.groups {
+		if err := group.check(isSet); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+}
+
+// isSet returns whether a config option was set explicitly, via a flag, an
+// environment variable, or one of the layers.
+func (p *configParser) isSet(opt ConfigOption, layers map[string]any) bool {
+	if opt.FlagName != "" {
+		if f := p.flags.Lookup(opt.FlagName); f != nil && f.Changed {
+			return true
+		}
+	}
+
+	if opt.ConfigKey == "" {
+		return false
+	}
+	for _, name := range p.optionEnvVars(opt) {
+		// viper ignores empty variables by default.
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			return true
+		}
+	}
+	_, ok := getNestedValue(layers, opt.ConfigKey)
+	return ok
+}
+
+// describeSources describes every way a config option can be set.
+func (p *configParser) describeSources(opt ConfigOption) string {
+	var ways []string
+	if opt.FlagName != "" {
+		ways = append(ways, "flag --"+opt.FlagName)
+	}
+	if opt.ConfigKey != "" {
+		vars := p.optionEnvVars(opt)
+		if len(vars) == 1 {
+			ways = append(ways, "environment variable "+vars[0])
+		} else {
+			ways = append(ways, "environment variables "+strings.Join(vars, ", "))
+		}
+		ways = append(ways, "key "+opt.ConfigKey+" in the config file")
+	}
+	return "set it via " + strings.Join(ways, ", or ")
+}
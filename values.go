@@ -0,0 +1,163 @@
+package configer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// readConfigValues decodes the configuration read from r, using the decoder
+// associated with configType. Keys are returned in lowercase, the same way
+// viper stores them.
+func readConfigValues(r io.Reader, configType string) (map[string]any, error) {
+	// A separate viper instance is used to avoid mixing the values with the
+	// defaults, environment variables and flags of the parser.
+	v := viper.New()
+	v.SetConfigType(configType)
+	if err := v.ReadConfig(r); err != nil {
+		return nil, err
+	}
+	return v.AllSettings(), nil
+}
+
+// flattenValues converts a nested configuration map into a map whose keys are
+// the full, "." separated config keys of the leaf values.
+func flattenValues(values map[string]any) map[string]any {
+	flat := make(map[string]any)
+	flattenInto(flat, "", values)
+	return flat
+}
+
+func flattenInto(flat map[string]any, prefix string, values map[string]any) {
+	for k, v := range values {
+		key := strings.ToLower(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := toStringMap(v); ok && len(nested) != 0 {
+			flattenInto(flat, key, nested)
+			continue
+		}
+		flat[key] = v
+	}
+}
+
+// toStringMap returns the value as a map with string keys, if the value is a
+// map. Some decoders (e.g. yaml) may produce maps with interface keys.
+func toStringMap(value any) (map[string]any, bool) {
+	switch m := value.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		converted := make(map[string]any, len(m))
+		for k, v := range m {
+			converted[fmt.Sprint(k)] = v
+		}
+		return converted, true
+	}
+	return nil, false
+}
+
+// mergeValues merges src into dst, recursively for nested maps. Values from
+// src take precedence over the values from dst.
+func mergeValues(dst, src map[string]any) {
+	for k, v := range src {
+		key := strings.ToLower(k)
+		srcMap, srcIsMap := toStringMap(v)
+		dstMap, dstIsMap := toStringMap(dst[key])
+		if srcIsMap && dstIsMap {
+			// Copy the destination map to avoid modifying the values of
+			// another source.
+			merged := make(map[string]any, len(dstMap))
+			mergeValues(merged, dstMap)
+			mergeValues(merged, srcMap)
+			dst[key] = merged
+			continue
+		}
+		if srcIsMap {
+			merged := make(map[string]any, len(srcMap))
+			mergeValues(merged, srcMap)
+			dst[key] = merged
+			continue
+		}
+		dst[key] = v
+	}
+}
+
+// copyValues returns a deep copy of a nested configuration map, including the
+// maps nested in lists.
+func copyValues(values map[string]any) map[string]any {
+	copied := make(map[string]any, len(values))
+	for k, v := range values {
+		copied[k] = copyValue(v)
+	}
+	return copied
+}
+
+func copyValue(value any) any {
+	if m, ok := toStringMap(value); ok {
+		return copyValues(m)
+	}
+	if list, ok := value.([]any); ok {
+		copied := make([]any, len(list))
+		for i, v := range list {
+			copied[i] = copyValue(v)
+		}
+		return copied
+	}
+	return value
+}
+
+// setNestedValue sets the value at the "." separated key of a nested
+// configuration map, creating the intermediate maps if necessary.
+func setNestedValue(values map[string]any, key string, value any) {
+	parts := strings.Split(strings.ToLower(key), ".")
+	current := values
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := toStringMap(current[part])
+		if !ok {
+			next = make(map[string]any)
+		}
+		current[part] = next
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// getNestedValue returns the value at the "." separated key of a nested
+// configuration map.
+func getNestedValue(values map[string]any, key string) (any, bool) {
+	parts := strings.Split(strings.ToLower(key), ".")
+	current := values
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := toStringMap(current[part])
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	value, ok := current[parts[len(parts)-1]]
+	return value, ok
+}
+
+// deleteNestedValue removes the value at the "." separated key of a nested
+// configuration map, and returns it.
+func deleteNestedValue(values map[string]any, key string) (any, bool) {
+	parts := strings.Split(strings.ToLower(key), ".")
+	current := values
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := toStringMap(current[part])
+		if !ok {
+			return nil, false
+		}
+		// Store the converted map, such that the deletion is visible.
+		current[part] = next
+		current = next
+	}
+	last := parts[len(parts)-1]
+	value, ok := current[last]
+	delete(current, last)
+	return value, ok
+}
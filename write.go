@@ -0,0 +1,267 @@
+package configer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+)
+
+// Default permissions of the config files and directories created by the
+// parser. Files holding secret values are only readable by the owner.
+const (
+	defaultFileMode       os.FileMode = 0o644
+	defaultSecretFileMode os.FileMode = 0o600
+	defaultDirMode        os.FileMode = 0o755
+)
+
+// WriteMode defines which configuration values are written via
+// --write-config.
+type WriteMode string
+
+const (
+	// WriteAll writes the values of all config keys.
+	WriteAll WriteMode = "all"
+	// WriteOverlay writes only the values which differ from the default
+	// values, i.e. the ones supplied via flags, environment variables, the
+	// configuration file or other sources. The written file is suitable as
+	// an overlay, which doesn't pin the default values.
+	WriteOverlay WriteMode = "overlay"
+	// WriteFlags writes only the values of the flags supplied on the command
+	// line.
+	WriteFlags WriteMode = "flags"
+)
+
+// writeSettings returns the configuration values written via the write flag,
+// according to the write mode. Encrypted values are written encrypted.
+func (p *configParser) writeSettings(mode WriteMode, opts []ConfigOption) (map[string]any, error) {
+	all := p.viper.AllSettings()
+
+	var settings map[string]any
+	switch mode {
+	case WriteAll, "":
+		settings = all
+
+	case WriteOverlay:
+		settings = make(map[string]any)
+		types := optionTypes(opts)
+		for key, value := range flattenValues(all) {
+			if def, ok := p.defaultValues[key]; ok && sameValue(value, def, types[key], p.listSeparator) {
+				continue
+			}
+			setNestedValue(settings, key, value)
+		}
+
+	case WriteFlags:
+		settings = make(map[string]any)
+		for _, opt := range opts {
+			if opt.FlagName == "" || opt.ConfigKey == "" {
+				continue
+			}
+			if f := p.flags.Lookup(opt.FlagName); f != nil && f.Changed {
+				setNestedValue(settings, opt.ConfigKey, p.viper.Get(opt.ConfigKey))
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid write mode %s", mode)
+	}
+
+	// Files written as a different version would not be migrated.
+	if p.configVersion != 0 {
+		settings[versionKey] = p.configVersion
+	}
+	return p.encryptedSettings(settings)
+}
+
+// sameValue returns whether two configuration values are equal. Values read
+// from different sources may have different types, e.g. a default duration
+// and a string read from a file, so they are compared after decoding them to
+// the type of prototype, the default value of their option. Values without an
+// option must be identical, since treating different values as equal could
+// drop an override.
+func sameValue(a, b, prototype any, listSeparator string) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	if prototype == nil {
+		return false
+	}
+
+	decodedA, err := decodeValue(a, prototype, listSeparator)
+	if err != nil {
+		return false
+	}
+	decodedB, err := decodeValue(b, prototype, listSeparator)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(decodedA, decodedB)
+}
+
+// writeConfig writes the configuration values to the file at configpath.
+// The file type is the one supplied via the config type flag, or is deduced
+// from the file extension, or the type specified via WithConfigType if the
+// file has no extension.
+//
+// The configuration is written to a temporary file in the same directory
+// first, which then replaces the file at configpath, such that the file is
+// never partially written. An existing file is only replaced if force is
+// set.
+func (p *configParser) writeConfig(configpath string, settings map[string]any, force bool) error {
+	dir := filepath.Dir(configpath)
+	if err := os.MkdirAll(dir, p.writeDirMode()); err != nil {
+		return fmt.Errorf("could not create directory %s: %w", dir, err)
+	}
+
+	data, err := encodeConfig(settings, p.flagFileFormat(configpath, nil))
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		err := writeFileExclusive(configpath, data, p.writeFileMode())
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("config file %s already exists, use --%s to overwrite it", configpath, forceFlagName())
+		}
+		return err
+	}
+
+	if _, err := os.Stat(configpath); err == nil && p.writeBackup {
+		backup := configpath + ".bak"
+		if err := copyFile(configpath, backup); err != nil {
+			return fmt.Errorf("could not back up %s: %w", configpath, err)
+		}
+		p.log.Printf("[configer info] backed up previous config at %s\n", backup)
+	}
+
+	if err := writeFileAtomic(configpath, data, p.writeFileMode()); err != nil {
+		return fmt.Errorf("could not replace %s: %w", configpath, err)
+	}
+	return nil
+}
+
+// encodeConfig encodes the configuration values in the given format. viper
+// only writes configurations to files, so they are written to an in-memory
+// file system first.
+func encodeConfig(settings map[string]any, format string) ([]byte, error) {
+	// A separate viper instance is used since the settings may differ from
+	// the ones of the parser.
+	memFs := afero.NewMemMapFs()
+	v := viper.New()
+	v.SetFs(memFs)
+	v.SetConfigType(format)
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("could not prepare settings: %w", err)
+	}
+
+	name := "config." + format
+	if err := v.WriteConfigAs(name); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(memFs, name)
+}
+
+// writeFileMode returns the permissions of the config files written by the
+// parser.
+func (p *configParser) writeFileMode() os.FileMode {
+	if p.fileMode != 0 {
+		return p.fileMode
+	}
+	if len(p.secretKeys()) != 0 {
+		return defaultSecretFileMode
+	}
+	return defaultFileMode
+}
+
+// writeDirMode returns the permissions of the directories created by the
+// parser when writing config files.
+func (p *configParser) writeDirMode() os.FileMode {
+	if p.dirMode != 0 {
+		return p.dirMode
+	}
+	return defaultDirMode
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of path,
+// and renames it into place, such that path is never partially written.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpPath, err := writeTempFile(path, data, perm)
+	if err != nil {
+		return err
+	}
+	// Does nothing if the file was renamed.
+	defer os.Remove(tmpPath)
+	return os.Rename(tmpPath, path)
+}
+
+// writeFileExclusive writes data to path like writeFileAtomic, but fails with
+// an error matching fs.ErrExist if path already exists. Unlike renaming it,
+// linking the temporary file into place fails if path was created in the
+// meantime.
+func writeFileExclusive(path string, data []byte, perm os.FileMode) error {
+	tmpPath, err := writeTempFile(path, data, perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	return os.Link(tmpPath, path)
+}
+
+// writeTempFile writes data to a new temporary file in the directory of path,
+// and returns the path of the temporary file.
+func writeTempFile(path string, data []byte, perm os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return "", fmt.Errorf("could not create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		if err = os.Chmod(tmpPath, perm); err != nil {
+			err = fmt.Errorf("could not set permissions of %s: %w", tmpPath, err)
+		}
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+// copyFile copies the file at src to dst, keeping its permissions.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, stat.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}